@@ -0,0 +1,229 @@
+package search
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/char/asciifolding"
+	"github.com/blevesearch/bleve/v2/analysis/char/html"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/stop"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/analysis/tokenmap"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/pkg/errors"
+)
+
+// NoStemmer is the AnalyzerConfig.Stemmer value which disables stemming.
+const NoStemmer = "none"
+
+// AnalyzerConfig defines a custom analyzer.
+// Its name may be used everywhere a language code is expected.
+type AnalyzerConfig struct {
+	Name string `json:"name"`
+
+	// Language is the code of the supported language the analyzer is based on:
+	// its normalization, stop words and stemmer are used unless changed below.
+	// Without language, text is only split into words and lowercased.
+	Language string `json:"language,omitempty"`
+
+	// StopWords are removed in addition to the language stop words.
+	StopWords []string `json:"stop_words,omitempty"`
+
+	// NoLanguageStopWords disables removal of the language stop words.
+	NoLanguageStopWords bool `json:"no_language_stop_words,omitempty"`
+
+	// Synonyms are rules in Solr format, see ParseSynonyms.
+	Synonyms []string `json:"synonyms,omitempty"`
+
+	// ASCIIFolding replaces letters with diacritics with their ASCII equivalents.
+	ASCIIFolding bool `json:"ascii_folding,omitempty"`
+
+	// StripHTML removes HTML tags before splitting text into words.
+	StripHTML bool `json:"strip_html,omitempty"`
+
+	// Stemmer is the name of bleve stemmer token filter, e.g. "stemmer_en_snowball".
+	// Empty value means the language stemmer, NoStemmer disables stemming.
+	Stemmer string `json:"stemmer,omitempty"`
+}
+
+// AnalyzersConfig is the content of the analyzers config file.
+type AnalyzersConfig struct {
+	Analyzers []AnalyzerConfig `json:"analyzers"`
+}
+
+// LoadAnalyzers reads analyzer definitions from the JSON config file.
+func LoadAnalyzers(path string) ([]AnalyzerConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	var config AnalyzersConfig
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return config.Analyzers, nil
+}
+
+// DefineAnalyzers defines custom analyzers in the registry cache,
+// so they can be used to analyze queries the same way documents were analyzed.
+func DefineAnalyzers(cache *registry.Cache, configs ...AnalyzerConfig) error {
+	for _, config := range configs {
+		components, err := config.components()
+		if err != nil {
+			return err
+		}
+
+		for _, c := range components {
+			switch c.kind {
+			case tokenMapComponent:
+				_, err = cache.DefineTokenMap(c.name, c.config)
+			case tokenFilterComponent:
+				_, err = cache.DefineTokenFilter(c.name, c.config)
+			case analyzerComponent:
+				_, err = cache.DefineAnalyzer(c.name, c.config)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to define analyzer %s", config.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// addAnalyzer adds custom analyzer to the index mapping,
+// which stores its definition along with the index.
+func addAnalyzer(m *mapping.IndexMappingImpl, config AnalyzerConfig) error {
+	components, err := config.components()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range components {
+		switch c.kind {
+		case tokenMapComponent:
+			err = m.AddCustomTokenMap(c.name, c.config)
+		case tokenFilterComponent:
+			err = m.AddCustomTokenFilter(c.name, c.config)
+		case analyzerComponent:
+			err = m.AddCustomAnalyzer(c.name, c.config)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to add analyzer %s", config.Name)
+		}
+	}
+	return nil
+}
+
+type componentKind int
+
+const (
+	tokenMapComponent componentKind = iota
+	tokenFilterComponent
+	analyzerComponent
+)
+
+// component is a bleve analysis component definition,
+// in the order it has to be defined.
+type component struct {
+	kind   componentKind
+	name   string
+	config map[string]interface{}
+}
+
+func (c AnalyzerConfig) components() ([]component, error) {
+	if c.Name == "" {
+		return nil, errors.New("analyzer name is required")
+	}
+	if IsLanguageSupported(c.Name) || c.Name == SuggestAnalyzer {
+		return nil, errors.Errorf("analyzer name %q is reserved", c.Name)
+	}
+
+	chain := analysisChain{tokenFilters: []string{lowercase.Name}}
+	if c.Language != "" {
+		var ok bool
+		chain, ok = languageChains[c.Language]
+		if !ok {
+			return nil, errors.Errorf("unsupported language %q of analyzer %s", c.Language, c.Name)
+		}
+	}
+
+	var charFilters []string
+	if c.StripHTML {
+		charFilters = append(charFilters, html.Name)
+	}
+	if c.ASCIIFolding {
+		charFilters = append(charFilters, asciifolding.Name)
+	}
+	charFilters = append(charFilters, chain.charFilters...)
+
+	var result []component
+	var extraFilters []string
+	if len(c.StopWords) > 0 {
+		tokens := make([]interface{}, len(c.StopWords))
+		for i, word := range c.StopWords {
+			tokens[i] = word
+		}
+		result = append(result,
+			component{tokenMapComponent, c.Name + "_stop_words", map[string]interface{}{
+				"type":   tokenmap.Name,
+				"tokens": tokens,
+			}},
+			component{tokenFilterComponent, c.Name + "_stop", map[string]interface{}{
+				"type":           stop.Name,
+				"stop_token_map": c.Name + "_stop_words",
+			}},
+		)
+		extraFilters = append(extraFilters, c.Name+"_stop")
+	}
+	if len(c.Synonyms) > 0 {
+		result = append(result, component{tokenFilterComponent, c.Name + "_synonyms", map[string]interface{}{
+			"type":     SynonymFilter,
+			"synonyms": c.Synonyms,
+		}})
+		extraFilters = append(extraFilters, c.Name+"_synonyms")
+	}
+
+	// custom stop words and synonyms go right after the language stop words,
+	// or after lowercasing if there are none, so they see normalized words
+	var tokenFilters []string
+	added := false
+	for _, filter := range chain.tokenFilters {
+		isStop := filter == chain.stop && chain.stop != ""
+		if !isStop || !c.NoLanguageStopWords {
+			tokenFilters = append(tokenFilters, filter)
+		}
+		if !added && (isStop || filter == lowercase.Name && chain.stop == "") {
+			tokenFilters = append(tokenFilters, extraFilters...)
+			added = true
+		}
+	}
+	if !added {
+		tokenFilters = append(tokenFilters, extraFilters...)
+	}
+
+	switch c.Stemmer {
+	case "":
+		if chain.stemmer != "" {
+			tokenFilters = append(tokenFilters, chain.stemmer)
+		}
+	case NoStemmer:
+	default:
+		tokenFilters = append(tokenFilters, c.Stemmer)
+	}
+
+	analyzer := map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": tokenFilters,
+	}
+	// bleve can't read back null char filters from the index mapping
+	if len(charFilters) > 0 {
+		analyzer["char_filters"] = charFilters
+	}
+	result = append(result, component{analyzerComponent, c.Name, analyzer})
+	return result, nil
+}
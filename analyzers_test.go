@@ -0,0 +1,108 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func terms(analyzer *analysis.Analyzer, text string) []string {
+	var result []string
+	for _, token := range analyzer.Analyze([]byte(text)) {
+		result = append(result, string(token.Term))
+	}
+	return result
+}
+
+func TestLanguageChains(t *testing.T) {
+	// Arabic presentation forms and ligatures are normalized by the Arabic analyzer
+	text := "The runners were running quickly through l'été's forests, Bücher и поиски ﺍﻟﻜﺘﺎﺏ ﻻ ﷲ"
+
+	cache := registry.NewCache()
+	for _, code := range SupportedLanguages() {
+		require.Contains(t, languageChains, code)
+
+		err := DefineAnalyzers(cache, AnalyzerConfig{Name: "custom_" + code, Language: code})
+		require.NoError(t, err, "failed to define analyzer based on %s", code)
+
+		stock, err := cache.AnalyzerNamed(code)
+		require.NoError(t, err)
+		custom, err := cache.AnalyzerNamed("custom_" + code)
+		require.NoError(t, err)
+
+		require.Equal(t, terms(stock, text), terms(custom, text), "analyzer based on %s differs", code)
+	}
+}
+
+func TestDefineAnalyzers(t *testing.T) {
+	cache := registry.NewCache()
+	err := DefineAnalyzers(cache,
+		AnalyzerConfig{
+			Name:                "stop",
+			Language:            "en",
+			StopWords:           []string{"please"},
+			NoLanguageStopWords: true,
+			Stemmer:             NoStemmer,
+		},
+		AnalyzerConfig{
+			Name:         "folding",
+			ASCIIFolding: true,
+			StripHTML:    true,
+			Synonyms:     []string{"tv => television"},
+		},
+		AnalyzerConfig{
+			Name:     "snowball",
+			Language: "en",
+			Stemmer:  "stemmer_en_snowball",
+		},
+	)
+	require.NoError(t, err)
+
+	analyzer, err := cache.AnalyzerNamed("stop")
+	require.NoError(t, err)
+	require.Equal(t, []string{"the", "running", "tests"}, terms(analyzer, "Please, the running tests"))
+
+	analyzer, err = cache.AnalyzerNamed("folding")
+	require.NoError(t, err)
+	require.Equal(t, []string{"creme", "brulee", "on", "television"}, terms(analyzer, "<p>Crème Brûlée</p> on TV"))
+
+	analyzer, err = cache.AnalyzerNamed("snowball")
+	require.NoError(t, err)
+	require.Equal(t, []string{"generous"}, terms(analyzer, "generously"))
+}
+
+func TestDefineAnalyzersErrors(t *testing.T) {
+	cache := registry.NewCache()
+
+	err := DefineAnalyzers(cache, AnalyzerConfig{Name: "en"})
+	require.EqualError(t, err, `analyzer name "en" is reserved`)
+
+	err = DefineAnalyzers(cache, AnalyzerConfig{Name: "docs", Language: "xx"})
+	require.EqualError(t, err, `unsupported language "xx" of analyzer docs`)
+
+	err = DefineAnalyzers(cache, AnalyzerConfig{Name: "docs", Language: "en", Stemmer: "stemmer_xx"})
+	require.Error(t, err)
+}
+
+func TestLoadAnalyzers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analyzers.json")
+	err := os.WriteFile(path, []byte(`{
+		"analyzers": [
+			{"name": "docs", "language": "en", "synonyms": ["k8s, kubernetes"], "stemmer": "none"}
+		]
+	}`), 0644)
+	require.NoError(t, err)
+
+	configs, err := LoadAnalyzers(path)
+	require.NoError(t, err)
+	require.Equal(t, []AnalyzerConfig{
+		{Name: "docs", Language: "en", Synonyms: []string{"k8s, kubernetes"}, Stemmer: NoStemmer},
+	}, configs)
+
+	_, err = LoadAnalyzers(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// contentFormats maps extensions of content files to their markup format.
+var contentFormats = map[string]string{
+	".md":       search.FormatMarkdown,
+	".markdown": search.FormatMarkdown,
+	".html":     search.FormatHTML,
+	".htm":      search.FormatHTML,
+}
+
+// page is a document indexed from a content file or a crawled web page.
+type page struct {
+	Title       string `indexer:"text,suggest"`
+	Description string `indexer:"text"`
+	Headings    string `indexer:"text"`
+	Content     string `indexer:"text"`
+	Tags        string `indexer:"text"`
+	Date        string `indexer:"date"`
+	Modified    string `indexer:"date"`
+	Path        string `indexer:"no_index"`
+	URL         string `indexer:"no_index"`
+
+	lang string
+}
+
+// pageKeys are front matter keys mapped to page fields, see readPage.
+var pageKeys = map[string]bool{
+	"title":       true,
+	"description": true,
+	"summary":     true,
+	"tags":        true,
+	"date":        true,
+	"lang":        true,
+	"language":    true,
+	"draft":       true,
+}
+
+func (p page) Type() string {
+	return "page"
+}
+
+func (p page) Language() string {
+	return p.lang
+}
+
+func runContent(args []string) (err error) {
+	flags := flag.NewFlagSet("content", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), "Usage: indexer content [flags] <content dir>\n\n")
+		flags.PrintDefaults()
+	}
+	indexPath := flags.String("index", "", "path of the index to create (required)")
+	buildDir := flags.String("build-dir", "", "directory for temporary files")
+	lang := flags.String("lang", "en", "language of pages without lang in front matter")
+	drafts := flags.Bool("drafts", false, "index pages with draft: true")
+	_ = flags.Parse(args)
+
+	if *indexPath == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("index path and content dir are required")
+	}
+
+	indexer, err := search.NewIndexer(*indexPath, *buildDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to create indexer")
+	}
+	defer discardOnError(indexer, &err)
+
+	if err := indexer.RegisterType(page{}, *lang); err != nil {
+		return errors.Wrap(err, "failed to register page type")
+	}
+
+	count, err := indexContent(indexer, flags.Arg(0), *drafts)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.Errorf("no pages found in %s", flags.Arg(0))
+	}
+	if err := indexer.Close(); err != nil {
+		return errors.Wrap(err, "failed to close indexer")
+	}
+
+	log.Printf("Indexed %d pages into %s", count, *indexPath)
+	return nil
+}
+
+// indexContent indexes Markdown and HTML files found in the directory,
+// skipping hidden files and directories. Documents IDs are paths of files
+// relative to the directory, without extensions, files with the same ID
+// (e.g. "post.md" and "post.html") are an error.
+// Front matter keys which are not mapped to page fields are logged.
+func indexContent(indexer *search.Indexer, dir string, drafts bool) (int, error) {
+	count := 0
+	files := map[string]string{} // file of each document ID
+	ignored := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		format, ok := contentFormats[strings.ToLower(filepath.Ext(path))]
+		if entry.IsDir() || !ok {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get relative path of %s", path)
+		}
+		rel = filepath.ToSlash(rel)
+
+		id := strings.TrimSuffix(rel, filepath.Ext(rel))
+		if other, ok := files[id]; ok {
+			return errors.Errorf("%s and %s have the same document ID %s", other, rel, id)
+		}
+		files[id] = rel
+
+		p, meta, err := readPage(path, format)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", rel)
+		}
+		for _, key := range meta.otherKeys(pageKeys) {
+			ignored[key] = true
+		}
+		if meta.bool("draft") && !drafts {
+			return nil
+		}
+		p.Path = rel
+
+		if err := indexer.Index(id, *p); err != nil {
+			return errors.Wrapf(err, "failed to index %s", rel)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to index content of %s", dir)
+	}
+	if len(ignored) > 0 {
+		keys := make([]string, 0, len(ignored))
+		for key := range ignored {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		log.Printf("Ignored front matter keys: %s", strings.Join(keys, ", "))
+	}
+	return count, nil
+}
+
+// readPage reads the content file and maps its front matter to page fields:
+// title, description (or summary), tags, date, lang (or language).
+// It also returns the front matter, e.g. to check whether the page is a draft.
+func readPage(path, format string) (*page, frontMatter, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read file")
+	}
+
+	meta, body, err := parseFrontMatter(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := search.StripMarkup(format, string(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &page{
+		Title:       meta.string("title"),
+		Description: meta.string("description", "summary"),
+		Content:     content,
+		Tags:        strings.Join(meta.strings("tags"), ", "),
+		Date:        meta.string("date"),
+		lang:        meta.string("lang", "language"),
+	}, meta, nil
+}
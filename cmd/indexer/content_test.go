@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func searchIDs(t *testing.T, index bleve.Index, q string) []string {
+	result, err := index.Search(bleve.NewSearchRequest(bleve.NewQueryStringQuery(q)))
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestIndexContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, filepath.Join(dir, "content"), map[string]string{
+		"posts/kubernetes.md": "---\ntitle: Deploying to Kubernetes\ntags: [k8s, ops]\ndate: 2022-05-01\n---\n" +
+			"## Rollback\n\nRead the [guide](https://example.com/helm).\n",
+		"posts/forms.html": "---\ntitle: Forms\n---\n<header>Signup</header><form>Fields</form>\n",
+		"posts/draft.md":   "---\ntitle: Unfinished\ndraft: true\n---\nDraft\n",
+		"about.html":       "+++\ntitle = \"About\"\nlang = \"ru\"\n+++\n<p>О проекте</p>\n",
+		"notes.txt":        "Not a page\n",
+		".hidden/x.md":     "Hidden\n",
+	})
+
+	indexPath := filepath.Join(dir, "index")
+	indexer, err := search.NewIndexer(indexPath, "")
+	require.NoError(t, err)
+	require.NoError(t, indexer.RegisterType(page{}, "en"))
+
+	count, err := indexContent(indexer, filepath.Join(dir, "content"), false)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.NoError(t, indexer.Close())
+
+	index, err := bleve.Open(indexPath)
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.Equal(t, []string{"posts/kubernetes"}, searchIDs(t, index, "Title:deploy"))
+	require.Equal(t, []string{"posts/kubernetes"}, searchIDs(t, index, "Tags:k8s"))
+	require.Equal(t, []string{"posts/kubernetes"}, searchIDs(t, index, "Content:guide"))
+	require.Empty(t, searchIDs(t, index, "Content:helm"))
+	require.Equal(t, []string{"posts/kubernetes"}, searchIDs(t, index, "Content:rollback"))
+	require.Empty(t, searchIDs(t, index, "Headings:rollback"))
+	// elements of the content are not boilerplate
+	require.Equal(t, []string{"posts/forms"}, searchIDs(t, index, "Content:signup"))
+	require.Equal(t, []string{"posts/forms"}, searchIDs(t, index, "Content:fields"))
+	require.Equal(t, []string{"about"}, searchIDs(t, index, "Content.ru:проект"))
+	require.Equal(t, []string{"posts/kubernetes"}, searchIDs(t, index, `Date:>="2022-01-01"`))
+	require.Empty(t, searchIDs(t, index, "unfinished"))
+	require.Empty(t, searchIDs(t, index, "hidden"))
+}
+
+func TestIndexContentErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"broken.md": "---\ntitle: Broken\n",
+	})
+
+	indexer, err := search.NewIndexer(filepath.Join(t.TempDir(), "index"), "")
+	require.NoError(t, err)
+	require.NoError(t, indexer.RegisterType(page{}, "en"))
+
+	_, err = indexContent(indexer, dir, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read broken.md")
+}
+
+func TestIndexContentSameID(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"post.md":   "---\ntitle: Markdown\n---\nText",
+		"post.html": "---\ntitle: HTML\n---\n<p>Text</p>",
+	})
+
+	indexer, err := search.NewIndexer(filepath.Join(t.TempDir(), "index"), "")
+	require.NoError(t, err)
+	require.NoError(t, indexer.RegisterType(page{}, "en"))
+
+	_, err = indexContent(indexer, dir, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "post.html and post.md have the same document ID post")
+}
+
+func TestRunContentDiscardsIndexOnError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"about.md":  "---\ntitle: About\n---\nHello",
+		"broken.md": "---\ntitle: Broken\n",
+	})
+	indexPath := filepath.Join(t.TempDir(), "index")
+	buildDir := filepath.Join(t.TempDir(), "build")
+
+	err := runContent([]string{"-index", indexPath, "-build-dir", buildDir, dir})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read broken.md")
+
+	_, err = os.Stat(indexPath)
+	require.True(t, os.IsNotExist(err), "partial index is not removed")
+	_, err = os.Stat(buildDir)
+	require.True(t, os.IsNotExist(err), "build dir is not removed")
+
+	emptyDir := t.TempDir()
+	err = runContent([]string{"-index", indexPath, emptyDir})
+	require.EqualError(t, err, "no pages found in "+emptyDir)
+	_, err = os.Stat(indexPath)
+	require.True(t, os.IsNotExist(err), "index without pages is created")
+}
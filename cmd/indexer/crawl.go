@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// maxSitemapDepth limits nesting of sitemap indexes.
+const maxSitemapDepth = 3
+
+// sitemap is a sitemap or a sitemap index, see https://www.sitemaps.org/protocol.html.
+type sitemap struct {
+	URLs     []sitemapURL `xml:"url"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// site reads sitemaps and pages by their URL path
+// from a directory on disk or from an HTTP server.
+type site struct {
+	dir    string
+	origin *url.URL
+	client *http.Client
+
+	// host is the host of URLs in sitemaps, the host of the first one
+	host string
+}
+
+// newSite returns the site the sitemap belongs to and the path of the sitemap on it.
+// Sitemap is an HTTP URL or a file path, the directory of the file is the site root.
+func newSite(location string, timeout time.Duration) (*site, string, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to parse sitemap URL %s", location)
+		}
+		return &site{
+			origin: &url.URL{Scheme: u.Scheme, Host: u.Host},
+			client: &http.Client{Timeout: timeout},
+		}, u.Path, nil
+	}
+
+	return &site{dir: filepath.Dir(location)}, "/" + filepath.Base(location), nil
+}
+
+// read returns the content at the URL path with the query. On disk, the query is ignored,
+// paths of directories and paths without extensions are resolved to index.html or .html files.
+func (s *site) read(urlPath, query string) ([]byte, error) {
+	urlPath = path.Clean("/" + urlPath)
+
+	if s.origin != nil {
+		u := s.origin.ResolveReference(&url.URL{Path: urlPath, RawQuery: query})
+		resp, err := s.client.Get(u.String())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s", u)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("failed to get %s: %s", u, resp.Status)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", u)
+		}
+		return data, nil
+	}
+
+	filePath := filepath.Join(s.dir, filepath.FromSlash(urlPath))
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		filePath = filepath.Join(filePath, "index.html")
+	} else if os.IsNotExist(err) && path.Ext(urlPath) == "" {
+		filePath += ".html"
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", filePath)
+	}
+	return data, nil
+}
+
+// readSitemap returns URLs of the sitemap, following sitemap indexes.
+func (s *site) readSitemap(urlPath string, depth int) ([]sitemapURL, error) {
+	if depth > maxSitemapDepth {
+		return nil, errors.Errorf("sitemap %s is nested too deep", urlPath)
+	}
+
+	data, err := s.read(urlPath, "")
+	if err != nil {
+		return nil, err
+	}
+	var result sitemap
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse sitemap %s", urlPath)
+	}
+
+	urls := result.URLs
+	for _, nested := range result.Sitemaps {
+		u, err := url.Parse(strings.TrimSpace(nested.Loc))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse sitemap URL %s", nested.Loc)
+		}
+		if !s.sameHost(u) {
+			return nil, errors.Errorf("sitemap %s is not on %s", nested.Loc, s.host)
+		}
+		nestedURLs, err := s.readSitemap(u.Path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, nestedURLs...)
+	}
+	return urls, nil
+}
+
+// sameHost reports whether the URL is on the host of the site,
+// which is the host of the first URL checked.
+func (s *site) sameHost(u *url.URL) bool {
+	if s.host == "" {
+		s.host = u.Host
+	}
+	return u.Host == s.host
+}
+
+func runCrawl(args []string) (err error) {
+	flags := flag.NewFlagSet("crawl", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), "Usage: indexer crawl [flags] <sitemap.xml path or URL>\n\n")
+		flags.PrintDefaults()
+	}
+	indexPath := flags.String("index", "", "path of the index to create (required)")
+	buildDir := flags.String("build-dir", "", "directory for temporary files")
+	lang := flags.String("lang", "en", "language of pages without supported lang attribute")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of HTTP requests")
+	_ = flags.Parse(args)
+
+	if *indexPath == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("index path and sitemap are required")
+	}
+
+	s, sitemapPath, err := newSite(flags.Arg(0), *timeout)
+	if err != nil {
+		return err
+	}
+
+	indexer, err := search.NewIndexer(*indexPath, *buildDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to create indexer")
+	}
+	defer discardOnError(indexer, &err)
+
+	if err := indexer.RegisterType(page{}, *lang); err != nil {
+		return errors.Wrap(err, "failed to register page type")
+	}
+
+	skipped := 0
+	count, err := crawl(indexer, s, sitemapPath, func(err error) {
+		log.Printf("Skipped page: %v", err)
+		skipped++
+	})
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		log.Printf("Skipped %d pages", skipped)
+	}
+	if count == 0 {
+		return errors.Errorf("no pages found in %s", flags.Arg(0))
+	}
+	if err := indexer.Close(); err != nil {
+		return errors.Wrap(err, "failed to close indexer")
+	}
+
+	log.Printf("Indexed %d pages into %s", count, *indexPath)
+	return nil
+}
+
+// crawl indexes pages listed in the sitemap, document IDs are URL paths with queries.
+// Pages which can't be read or are on other hosts are passed to skip.
+func crawl(indexer *search.Indexer, s *site, sitemapPath string, skip func(err error)) (int, error) {
+	urls, err := s.readSitemap(sitemapPath, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := map[string]bool{}
+	count := 0
+	for _, entry := range urls {
+		loc := strings.TrimSpace(entry.Loc)
+		u, err := url.Parse(loc)
+		if err != nil {
+			skip(errors.Wrapf(err, "failed to parse URL %s", loc))
+			continue
+		}
+		if !s.sameHost(u) {
+			skip(errors.Errorf("page %s is not on %s", loc, s.host))
+			continue
+		}
+		id := u.Path
+		if id == "" {
+			id = "/"
+		}
+		if u.RawQuery != "" {
+			id += "?" + u.RawQuery
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		source, err := s.read(u.Path, u.RawQuery)
+		if err != nil {
+			skip(err)
+			continue
+		}
+		extracted, err := extractHTML(source)
+		if err != nil {
+			skip(errors.Wrapf(err, "failed to extract %s", loc))
+			continue
+		}
+
+		p := page{
+			Title:       extracted.title,
+			Description: extracted.description,
+			Headings:    strings.Join(extracted.headings, "\n"),
+			Content:     extracted.content,
+			Modified:    strings.TrimSpace(entry.LastMod),
+			URL:         loc,
+			lang:        pageLanguage(extracted.lang),
+		}
+		if err := indexer.Index(id, p); err != nil {
+			return count, errors.Wrapf(err, "failed to index %s", loc)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// pageLanguage returns the supported language of the lang attribute,
+// e.g. "en" for "en-US", or empty string.
+func pageLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		lang = lang[:i]
+	}
+	if !search.IsLanguageSupported(lang) {
+		return ""
+	}
+	return lang
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+var testSiteFiles = map[string]string{
+	"sitemap.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://example.com/posts/sitemap.xml</loc></sitemap>
+</sitemapindex>`,
+	"posts/sitemap.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/posts/deploy/</loc><lastmod>2022-05-01</lastmod></url>
+	<url><loc>https://example.com/posts/about.html</loc></url>
+	<url><loc>https://example.com/posts/missing/</loc></url>
+	<url><loc>https://example.com/posts/deploy/</loc></url>
+	<url><loc>https://example.com/posts/list.html?page=2</loc></url>
+	<url><loc>https://other.com/posts/about.html</loc></url>
+</urlset>`,
+	"posts/deploy/index.html": `<html lang="en"><head><title>Deploying</title>
+<meta name="description" content="Deployment guide"></head>
+<body><nav>Menu</nav><main><h1>Deploying</h1><h2>Rollback</h2><p>Undo releases.</p></main></body></html>`,
+	"posts/about.html": `<html lang="ru"><head><title>О сайте</title></head><body><p>Серверы и сервисы</p></body></html>`,
+	"posts/list.html":  `<html><head><title>Posts</title></head><body><p>Archive</p></body></html>`,
+}
+
+func testCrawl(t *testing.T, location string) bleve.Index {
+	indexPath := filepath.Join(t.TempDir(), "index")
+	indexer, err := search.NewIndexer(indexPath, "")
+	require.NoError(t, err)
+	require.NoError(t, indexer.RegisterType(page{}, "en"))
+
+	s, sitemapPath, err := newSite(location, time.Second)
+	require.NoError(t, err)
+
+	var skipped []error
+	count, err := crawl(indexer, s, sitemapPath, func(err error) {
+		skipped = append(skipped, err)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.Len(t, skipped, 2)
+	require.NoError(t, indexer.Close())
+
+	index, err := bleve.Open(indexPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { index.Close() })
+
+	require.Equal(t, []string{"/posts/deploy/"}, searchIDs(t, index, "Title:deploy"))
+	require.Equal(t, []string{"/posts/deploy/"}, searchIDs(t, index, "Description:guide"))
+	require.Equal(t, []string{"/posts/deploy/"}, searchIDs(t, index, "Headings:rollback"))
+	require.Equal(t, []string{"/posts/deploy/"}, searchIDs(t, index, "Content:releases"))
+	require.Equal(t, []string{"/posts/deploy/"}, searchIDs(t, index, `Modified:>="2022-01-01"`))
+	require.Empty(t, searchIDs(t, index, `Date:>="2022-01-01"`))
+	require.Equal(t, []string{"/posts/list.html?page=2"}, searchIDs(t, index, "Content:archive"))
+	require.Empty(t, searchIDs(t, index, "Content:menu"))
+	require.Equal(t, []string{"/posts/about.html"}, searchIDs(t, index, "Content.ru:сервер"))
+	return index
+}
+
+func TestCrawlHTTP(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, testSiteFiles)
+	var queries []string
+	fileServer := http.FileServer(http.Dir(dir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" {
+			queries = append(queries, r.URL.RawQuery)
+		}
+		fileServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	index := testCrawl(t, server.URL+"/sitemap.xml")
+	require.Equal(t, []string{"page=2"}, queries)
+
+	doc, err := index.Document("/posts/about.html")
+	require.NoError(t, err)
+	require.NotNil(t, doc)
+}
+
+func TestCrawlDisk(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, testSiteFiles)
+
+	testCrawl(t, filepath.Join(dir, "sitemap.xml"))
+}
+
+func TestCrawlSitemapOnOtherHost(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"sitemap.xml": `<sitemapindex>
+	<sitemap><loc>https://example.com/posts.xml</loc></sitemap>
+	<sitemap><loc>https://other.com/pages.xml</loc></sitemap>
+</sitemapindex>`,
+		"posts.xml": `<urlset></urlset>`,
+		"pages.xml": `<urlset></urlset>`,
+	})
+
+	s, sitemapPath, err := newSite(filepath.Join(dir, "sitemap.xml"), time.Second)
+	require.NoError(t, err)
+	_, err = s.readSitemap(sitemapPath, 0)
+	require.EqualError(t, err, "sitemap https://other.com/pages.xml is not on example.com")
+}
+
+func TestPageLanguage(t *testing.T) {
+	require.Equal(t, "en", pageLanguage("en-US"))
+	require.Equal(t, "pt", pageLanguage("pt_BR"))
+	require.Equal(t, "", pageLanguage("xx"))
+	require.Equal(t, "", pageLanguage(""))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis/datetime/optional"
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// dateParser parses dates the same way bleve does for date fields.
+var dateParser, _ = optional.DateTimeParserConstructor(nil, nil)
+
+// ingestCSV indexes rows of the CSV file with a header, column names are field names.
+// Empty values are skipped, others are converted to kinds of the type fields.
+// Malformed rows are reported with their line numbers and skipped.
+func ingestCSV(indexer *search.Indexer, r io.Reader, name string, options ingestOptions) (int, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "%s: failed to read header", name)
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.TrimSpace(column)
+	}
+	columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+
+	count := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return count, errors.Wrapf(err, "failed to read %s", name)
+			}
+			options.malformed(errors.Wrap(err, name))
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		fields := search.Fields{}
+		for i, value := range record {
+			if value != "" {
+				fields[columns[i]] = value
+			}
+		}
+
+		id, doc, err := options.document(fields)
+		if err == nil {
+			err = convertValues(columns, doc.Fields, options.config.Types[doc.DocType].Fields)
+		}
+		if err != nil {
+			options.malformed(errors.Wrapf(err, "%s:%d", name, line))
+			continue
+		}
+
+		if err := indexer.Index(id, doc); err != nil {
+			return count, errors.Wrapf(err, "%s:%d: failed to index document %s", name, line, id)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// convertValues converts string values of number, bool and date fields
+// to values of their kinds, in the order of columns.
+func convertValues(columns []string, fields search.Fields, tags map[string]string) error {
+	for _, column := range columns {
+		value, ok := fields[column].(string)
+		if !ok {
+			continue
+		}
+
+		kind, _, _ := strings.Cut(tags[column], ",")
+		switch strings.TrimSpace(kind) {
+		case "number":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return errors.Errorf("invalid number %q in column %s", value, column)
+			}
+			fields[column] = number
+
+		case "bool":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.Errorf("invalid bool %q in column %s", value, column)
+			}
+			fields[column] = b
+
+		case "date":
+			date, err := dateParser.ParseDateTime(value)
+			if err != nil {
+				return errors.Errorf("invalid date %q in column %s", value, column)
+			}
+			fields[column] = date
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestCSV(t *testing.T) {
+	config := &ingestConfig{
+		Language:    "en",
+		DefaultType: "product",
+		Types: map[string]typeConfig{
+			"product": {Fields: map[string]string{
+				"name":     "text",
+				"sku":      "keyword",
+				"price":    "number",
+				"in_stock": "bool",
+				"added":    "date",
+			}},
+		},
+	}
+	indexer, indexPath := newIngestIndexer(t, config)
+
+	input := "\ufeffsku, name ,price,in_stock,added\n" +
+		"CB-01,Network cable,5.5,true,2022-05-01\n" +
+		"RT-02,\"Router, dual band\",150,false,\n" +
+		"BAD-1,Broken price,cheap,true,2022-05-01\n" +
+		"BAD-2,Too,many,columns,here,!\n" +
+		",No ID,1,true,2022-05-01\n" +
+		"BAD-3,Broken date,1,true,yesterday\n" +
+		"BAD-4,\"Bare \"quote,1,true,2022-05-01\n" +
+		"SW-03,Switch,30,1,2022-06-01T10:00:00Z\n"
+
+	var malformed []string
+	options := ingestOptions{
+		config:    config,
+		idField:   "sku",
+		typeField: "type",
+		malformed: func(err error) {
+			malformed = append(malformed, err.Error())
+		},
+	}
+	count, err := ingestCSV(indexer, strings.NewReader(input), "products.csv", options)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.Len(t, malformed, 5)
+	require.Equal(t, []string{
+		`products.csv:4: invalid number "cheap" in column price`,
+		"products.csv: record on line 5: wrong number of fields",
+		"products.csv:6: invalid sku field: ID is required",
+		`products.csv:7: invalid date "yesterday" in column added`,
+	}, malformed[:4])
+	// column of the parse error depends on Go version
+	require.Contains(t, malformed[4], "products.csv: parse error on line 8")
+	require.NoError(t, indexer.Close())
+
+	index, err := bleve.Open(indexPath)
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.Equal(t, []string{"RT-02"}, searchIDs(t, index, "name:router"))
+	require.Equal(t, []string{"RT-02", "SW-03"}, searchIDs(t, index, "price:>=30"))
+	require.Equal(t, []string{"SW-03"}, searchIDs(t, index, `added:>"2022-05-15"`))
+
+	inStock := bleve.NewBoolFieldQuery(true)
+	inStock.SetField("in_stock")
+	result, err := index.Search(bleve.NewSearchRequest(inStock))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), result.Total)
+}
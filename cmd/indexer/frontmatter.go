@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter is metadata at the beginning of a content file.
+type frontMatter map[string]interface{}
+
+// parseFrontMatter separates front matter from the content of the file:
+// YAML between "---" lines or TOML between "+++" lines, as Hugo and Jekyll use.
+// Files without front matter have empty one.
+func parseFrontMatter(source []byte) (frontMatter, []byte, error) {
+	source = bytes.TrimPrefix(source, []byte("\ufeff"))
+
+	lines := bytes.SplitAfter(source, []byte("\n"))
+	delimiter := string(bytes.TrimSpace(lines[0]))
+	if delimiter != "---" && delimiter != "+++" {
+		return frontMatter{}, source, nil
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if string(bytes.TrimSpace(lines[i])) == delimiter {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return nil, nil, errors.Errorf("front matter is not closed with %q", delimiter)
+	}
+
+	header := bytes.Join(lines[1:end], nil)
+	content := bytes.Join(lines[end+1:], nil)
+
+	result := frontMatter{}
+	var err error
+	switch delimiter {
+	case "---":
+		err = yaml.Unmarshal(header, &result)
+	case "+++":
+		err = toml.Unmarshal(header, &result)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse front matter")
+	}
+	return result, content, nil
+}
+
+// string returns the value of the first of keys which is set, formatted as a string.
+func (f frontMatter) string(keys ...string) string {
+	for _, key := range keys {
+		switch value := f[key].(type) {
+		case nil:
+			continue
+		case string:
+			return value
+		case time.Time:
+			return value.Format(time.RFC3339)
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// strings returns the list of the first of keys which is set,
+// a single value is a list of one item.
+func (f frontMatter) strings(keys ...string) []string {
+	for _, key := range keys {
+		switch value := f[key].(type) {
+		case nil:
+			continue
+		case []interface{}:
+			var result []string
+			for _, item := range value {
+				result = append(result, fmt.Sprint(item))
+			}
+			return result
+		default:
+			if s := strings.TrimSpace(f.string(key)); s != "" {
+				return []string{s}
+			}
+		}
+	}
+	return nil
+}
+
+// bool reports whether the key is set to true.
+func (f frontMatter) bool(key string) bool {
+	value, _ := f[key].(bool)
+	return value
+}
+
+// otherKeys returns sorted keys which are not in known.
+func (f frontMatter) otherKeys(known map[string]bool) []string {
+	var result []string
+	for key := range f {
+		if !known[key] {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
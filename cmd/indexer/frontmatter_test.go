@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tt := []struct {
+		name        string
+		source      string
+		wantMeta    frontMatter
+		wantContent string
+		wantErr     bool
+	}{
+		{
+			name:        "yaml",
+			source:      "---\ntitle: Hello\ntags: [go, search]\n---\n# Body\n",
+			wantMeta:    frontMatter{"title": "Hello", "tags": []interface{}{"go", "search"}},
+			wantContent: "# Body\n",
+		},
+		{
+			name:        "toml",
+			source:      "+++\r\ntitle = \"Hello\"\r\ndraft = true\r\n+++\r\nBody",
+			wantMeta:    frontMatter{"title": "Hello", "draft": true},
+			wantContent: "Body",
+		},
+		{
+			name:        "none",
+			source:      "# Title\n---\n",
+			wantMeta:    frontMatter{},
+			wantContent: "# Title\n---\n",
+		},
+		{
+			name:    "not closed",
+			source:  "---\ntitle: Hello\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			source:  "---\ntitle: [\n---\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, content, err := parseFrontMatter([]byte(tc.source))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantMeta, meta)
+			require.Equal(t, tc.wantContent, string(content))
+		})
+	}
+}
+
+func TestFrontMatterValues(t *testing.T) {
+	meta, _, err := parseFrontMatter([]byte("---\ndate: 2022-05-01T10:00:00Z\nsummary: Short\ntags: go\nweight: 3\n---\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, "2022-05-01T10:00:00Z", meta.string("date"))
+	require.Equal(t, "Short", meta.string("description", "summary"))
+	require.Equal(t, "3", meta.string("weight"))
+	require.Equal(t, []string{"go"}, meta.strings("tags"))
+	require.Empty(t, meta.strings("categories"))
+	require.False(t, meta.bool("draft"))
+	require.Equal(t, []string{"weight"}, meta.otherKeys(pageKeys))
+}
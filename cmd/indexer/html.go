@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/chuhlomin/search"
+)
+
+// htmlPage is the text extracted from an HTML page.
+type htmlPage struct {
+	title       string
+	description string
+	lang        string
+	headings    []string
+	content     string
+}
+
+// boilerplateElements are removed from the main content of pages.
+var boilerplateElements = map[atom.Atom]bool{
+	atom.Nav:    true,
+	atom.Header: true,
+	atom.Footer: true,
+	atom.Aside:  true,
+	atom.Form:   true,
+}
+
+// extractHTML extracts the title, meta description, language, headings
+// and text of the main content of the page: <main>, <article> or <body>
+// element, without navigation, headers, footers and sidebars.
+func extractHTML(source []byte) (*htmlPage, error) {
+	doc, err := html.Parse(bytes.NewReader(source))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse HTML")
+	}
+
+	page := &htmlPage{}
+	if root := findElement(doc, atom.Html); root != nil {
+		page.lang = attr(root, "lang")
+	}
+	if title := findElement(doc, atom.Title); title != nil {
+		page.title = nodeText(title)
+	}
+	description := findNode(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.DataAtom == atom.Meta && strings.EqualFold(attr(n, "name"), "description")
+	})
+	if description != nil {
+		page.description = strings.TrimSpace(attr(description, "content"))
+	}
+
+	main := findElement(doc, atom.Main)
+	if main == nil {
+		main = findElement(doc, atom.Article)
+	}
+	if main == nil {
+		main = findElement(doc, atom.Body)
+	}
+	if main == nil {
+		return page, nil
+	}
+
+	removeElements(main, boilerplateElements)
+
+	var headings func(n *html.Node)
+	headings = func(n *html.Node) {
+		if n.Type == html.ElementNode && search.IsHeading(n.DataAtom) {
+			if text := nodeText(n); text != "" {
+				page.headings = append(page.headings, text)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			headings(c)
+		}
+	}
+	headings(main)
+
+	var buf bytes.Buffer
+	for c := main.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return nil, errors.Wrap(err, "failed to render content")
+		}
+	}
+	page.content, err = search.StripMarkup(search.FormatHTML, buf.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if page.title == "" && len(page.headings) > 0 {
+		page.title = page.headings[0]
+	}
+	return page, nil
+}
+
+// findElement returns the first element of the kind in the tree, depth-first.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	return findNode(n, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.DataAtom == a
+	})
+}
+
+// findNode returns the first node matching the condition, depth-first.
+func findNode(n *html.Node, match func(n *html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// removeElements removes descendants of the node of given kinds.
+func removeElements(n *html.Node, elements map[atom.Atom]bool) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && elements[c.DataAtom] {
+			n.RemoveChild(c)
+		} else {
+			removeElements(c, elements)
+		}
+		c = next
+	}
+}
+
+// nodeText returns text of the node with collapsed spaces.
+func nodeText(n *html.Node) string {
+	var text strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractHTML(t *testing.T) {
+	source := `<!DOCTYPE html>
+<html lang="en-US">
+<head>
+	<title>Deploying | Blog</title>
+	<meta name="Description" content=" How to deploy ">
+	<style>body { color: red }</style>
+</head>
+<body>
+	<nav><a href="/">Home</a></nav>
+	<main>
+		<h1>Deploy<em>ing</em> services</h1>
+		<p>Use the <a href="https://example.com/helm">chart</a>.</p>
+		<aside>Related posts</aside>
+		<h2>Rollback</h2>
+		<p>Undo it.</p>
+		<script>track()</script>
+	</main>
+	<footer>Copyright</footer>
+</body>
+</html>`
+
+	page, err := extractHTML([]byte(source))
+	require.NoError(t, err)
+	require.Equal(t, &htmlPage{
+		title:       "Deploying | Blog",
+		description: "How to deploy",
+		lang:        "en-US",
+		headings:    []string{"Deploying services", "Rollback"},
+		content:     "Deploying services\nUse the chart.\nRollback\nUndo it.",
+	}, page)
+}
+
+func TestExtractHTMLFallbacks(t *testing.T) {
+	page, err := extractHTML([]byte(`<header>Site</header><article><h1>Title</h1><p>Text</p></article><p>Comments</p>`))
+	require.NoError(t, err)
+	require.Equal(t, "Title", page.title)
+	require.Equal(t, "Title\nText", page.content)
+
+	page, err = extractHTML([]byte(`<nav>Menu</nav><p>Just text</p>`))
+	require.NoError(t, err)
+	require.Equal(t, "", page.title)
+	require.Equal(t, "Just text", page.content)
+}
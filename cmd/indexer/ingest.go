@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+const (
+	formatJSONL  = "jsonl"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// ingestFunc indexes documents read from r, name is used in errors.
+type ingestFunc func(indexer *search.Indexer, r io.Reader, name string, options ingestOptions) (int, error)
+
+var ingestFormats = map[string]ingestFunc{
+	formatJSONL:  ingestJSONL,
+	formatNDJSON: ingestJSONL,
+	formatCSV:    ingestCSV,
+}
+
+// ingestConfig defines types of ingested documents and their fields.
+type ingestConfig struct {
+	// Language is the default language of types.
+	Language string `json:"language"`
+
+	// DefaultType is the type of documents without type field.
+	DefaultType string `json:"default_type"`
+
+	Types map[string]typeConfig `json:"types"`
+}
+
+// typeConfig defines a document type: its language
+// and fields with `indexer` tags, e.g. {"title": "text,suggest"}.
+type typeConfig struct {
+	Language string            `json:"language"`
+	Fields   map[string]string `json:"fields"`
+}
+
+func loadIngestConfig(path string) (*ingestConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	var config ingestConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	if len(config.Types) == 0 {
+		return nil, errors.Errorf("no types defined in %s", path)
+	}
+	if config.DefaultType != "" {
+		if _, ok := config.Types[config.DefaultType]; !ok {
+			return nil, errors.Errorf("default type %q is not defined in %s", config.DefaultType, path)
+		}
+	}
+	return &config, nil
+}
+
+// register registers all configured types in the indexer.
+func (c *ingestConfig) register(indexer *search.Indexer) error {
+	for name, t := range c.Types {
+		lang := t.Language
+		if lang == "" {
+			lang = c.Language
+		}
+		if err := indexer.RegisterFields(name, t.Fields, lang); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestOptions define how ID, type and language of documents are found in their fields.
+type ingestOptions struct {
+	config    *ingestConfig
+	idField   string
+	typeField string
+	langField string
+
+	// malformed is called for skipped malformed records.
+	malformed func(err error)
+}
+
+// document makes a document of the configured type from fields.
+// ID, type and language fields are removed from fields:
+// they are the document ID, type and language.
+func (o ingestOptions) document(fields search.Fields) (string, search.Document, error) {
+	id, err := idValue(fields[o.idField])
+	if err != nil {
+		return "", search.Document{}, errors.Wrapf(err, "invalid %s field", o.idField)
+	}
+	delete(fields, o.idField)
+
+	docType := o.config.DefaultType
+	if value, ok := fields[o.typeField]; ok {
+		docType, ok = value.(string)
+		if !ok {
+			return "", search.Document{}, errors.Errorf("%s field must be a string, got %v", o.typeField, value)
+		}
+		delete(fields, o.typeField)
+	}
+	if docType == "" {
+		return "", search.Document{}, errors.Errorf("%s field is required", o.typeField)
+	}
+	if _, ok := o.config.Types[docType]; !ok {
+		return "", search.Document{}, errors.Errorf("unknown type %q", docType)
+	}
+
+	lang := ""
+	if value, ok := fields[o.langField]; ok && o.langField != "" {
+		lang, ok = value.(string)
+		if !ok {
+			return "", search.Document{}, errors.Errorf("%s field must be a string, got %v", o.langField, value)
+		}
+		delete(fields, o.langField)
+	}
+
+	return id, search.Document{Fields: fields, DocType: docType, Lang: lang}, nil
+}
+
+// idValue formats string or integer ID.
+func idValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", errors.New("ID is required")
+	case string:
+		if value == "" {
+			return "", errors.New("ID is required")
+		}
+		return value, nil
+	case float64:
+		if value != float64(int64(value)) {
+			return "", errors.Errorf("ID must be a string or an integer, got %v", value)
+		}
+		return strconv.FormatInt(int64(value), 10), nil
+	}
+	return "", errors.Errorf("ID must be a string or an integer, got %v", value)
+}
+
+func runIngest(args []string) (err error) {
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), "Usage: indexer ingest [flags] [files]\n\nReads standard input if no files are given.\n\n")
+		flags.PrintDefaults()
+	}
+	indexPath := flags.String("index", "", "path of the index to create (required)")
+	buildDir := flags.String("build-dir", "", "directory for temporary files")
+	configPath := flags.String("config", "", "path of the JSON file with types of documents (required)")
+	format := flags.String("format", formatJSONL, "format of input: jsonl (or ndjson), csv")
+	idField := flags.String("id-field", "id", "field with document ID")
+	typeField := flags.String("type-field", "type", "field with document type")
+	langField := flags.String("lang-field", "lang", "field with document language")
+	detectLanguages := flags.String("detect-language", "", "comma-separated languages to detect language of documents without language field among")
+	_ = flags.Parse(args)
+
+	if *indexPath == "" || *configPath == "" {
+		flags.Usage()
+		return errors.New("index path and config are required")
+	}
+	ingest, ok := ingestFormats[*format]
+	if !ok {
+		return errors.Errorf("unsupported format %q", *format)
+	}
+
+	config, err := loadIngestConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	indexer, err := search.NewIndexer(*indexPath, *buildDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to create indexer")
+	}
+	defer discardOnError(indexer, &err)
+
+	if *detectLanguages != "" {
+		detector := search.NewLanguageDetector(strings.Split(*detectLanguages, ",")...)
+		if detector.Languages() == 0 {
+			return errors.Errorf("language detection is not supported for %s", *detectLanguages)
+		}
+		indexer.SetLanguageDetector(detector)
+	}
+	if err := config.register(indexer); err != nil {
+		return err
+	}
+
+	skipped := 0
+	options := ingestOptions{
+		config:    config,
+		idField:   *idField,
+		typeField: *typeField,
+		langField: *langField,
+		malformed: func(err error) {
+			log.Printf("Skipped malformed record: %v", err)
+			skipped++
+		},
+	}
+
+	count := 0
+	if flags.NArg() == 0 {
+		count, err = ingest(indexer, os.Stdin, "stdin", options)
+		if err != nil {
+			return err
+		}
+	}
+	for _, path := range flags.Args() {
+		n, err := ingestFile(ingest, indexer, path, options)
+		if err != nil {
+			return err
+		}
+		count += n
+	}
+	if skipped > 0 {
+		log.Printf("Skipped %d malformed records", skipped)
+	}
+
+	if count == 0 {
+		return errors.New("no documents found")
+	}
+	if err := indexer.Close(); err != nil {
+		return errors.Wrap(err, "failed to close indexer")
+	}
+
+	log.Printf("Indexed %d documents into %s", count, *indexPath)
+	return nil
+}
+
+func ingestFile(ingest ingestFunc, indexer *search.Indexer, path string, options ingestOptions) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+
+	return ingest(indexer, f, path, options)
+}
+
+// maxLineSize limits the size of a JSON document on one line.
+const maxLineSize = 16 * 1024 * 1024
+
+// ingestJSONL indexes JSON documents, one per line, skipping empty lines.
+func ingestJSONL(indexer *search.Indexer, r io.Reader, name string, options ingestOptions) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	count := 0
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		var fields search.Fields
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return count, errors.Wrapf(err, "%s:%d: failed to parse document", name, line)
+		}
+		id, doc, err := options.document(fields)
+		if err != nil {
+			return count, errors.Wrapf(err, "%s:%d", name, line)
+		}
+		if err := indexer.Index(id, doc); err != nil {
+			return count, errors.Wrapf(err, "%s:%d: failed to index document %s", name, line, id)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, errors.Wrapf(err, "failed to read %s", name)
+	}
+	return count, nil
+}
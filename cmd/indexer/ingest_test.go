@@ -0,0 +1,155 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+func newIngestIndexer(t *testing.T, config *ingestConfig) (*search.Indexer, string) {
+	indexPath := filepath.Join(t.TempDir(), "index")
+	indexer, err := search.NewIndexer(indexPath, "")
+	require.NoError(t, err)
+	require.NoError(t, config.register(indexer))
+	return indexer, indexPath
+}
+
+func TestLoadIngestConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":  `{"language": "en", "default_type": "post", "types": {"post": {"fields": {"title": "text"}}}}`,
+		"empty.json":   `{}`,
+		"default.json": `{"default_type": "page", "types": {"post": {}}}`,
+	})
+
+	config, err := loadIngestConfig(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.Equal(t, "post", config.DefaultType)
+	require.Equal(t, map[string]string{"title": "text"}, config.Types["post"].Fields)
+
+	_, err = loadIngestConfig(filepath.Join(dir, "empty.json"))
+	require.Error(t, err)
+
+	_, err = loadIngestConfig(filepath.Join(dir, "default.json"))
+	require.Error(t, err)
+}
+
+func TestIngestJSONL(t *testing.T) {
+	config := &ingestConfig{
+		Language: "en",
+		Types: map[string]typeConfig{
+			"post":    {Fields: map[string]string{"title": "text,suggest", "body": "text,format=markdown"}},
+			"product": {Language: "ru", Fields: map[string]string{"name": "text"}},
+		},
+	}
+	indexer, indexPath := newIngestIndexer(t, config)
+
+	input := `{"id": "hello", "type": "post", "title": "Deploying services", "body": "[Guide](https://example.com/helm)"}
+
+{"id": 42, "type": "product", "name": "Сервер"}
+`
+	options := ingestOptions{config: config, idField: "id", typeField: "type"}
+	count, err := ingestJSONL(indexer, strings.NewReader(input), "input", options)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.NoError(t, indexer.Close())
+
+	index, err := bleve.Open(indexPath)
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.Equal(t, []string{"hello"}, searchIDs(t, index, "title:deploy"))
+	require.Equal(t, []string{"hello"}, searchIDs(t, index, "body:guide"))
+	require.Empty(t, searchIDs(t, index, "body:helm"))
+	require.Equal(t, []string{"42"}, searchIDs(t, index, "name:сервер"))
+	require.Equal(t, []string{"42"}, searchIDs(t, index, "_type:product"))
+	require.Empty(t, searchIDs(t, index, "type:product"))
+}
+
+func TestRunIngestLanguages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json": `{"language": "en", "default_type": "post", "types": {"post": {"fields": {"title": "text"}}}}`,
+		"posts.jsonl": `{"id": "en", "title": "Deploying services"}
+{"id": "ru", "title": "Развертывание сервисов"}
+{"id": "de", "title": "Dienste bereitstellen", "lang": "de"}
+`,
+	})
+	indexPath := filepath.Join(t.TempDir(), "index")
+
+	err := runIngest([]string{
+		"-index", indexPath,
+		"-config", filepath.Join(dir, "config.json"),
+		"-detect-language", "en,ru",
+		filepath.Join(dir, "posts.jsonl"),
+	})
+	require.NoError(t, err)
+
+	index, err := bleve.Open(indexPath)
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.Equal(t, []string{"en"}, searchIDs(t, index, "_lang:en"))
+	require.Equal(t, []string{"ru"}, searchIDs(t, index, "_lang:ru"))
+	require.Equal(t, []string{"de"}, searchIDs(t, index, "_lang:de"))
+	require.Equal(t, []string{"ru"}, searchIDs(t, index, "title.ru:сервис"))
+	require.Equal(t, []string{"de"}, searchIDs(t, index, "title.de:dienst"))
+	require.Empty(t, searchIDs(t, index, "lang:de"))
+
+	err = runIngest([]string{
+		"-index", filepath.Join(t.TempDir(), "index"),
+		"-config", filepath.Join(dir, "config.json"),
+		"-detect-language", "xx",
+		filepath.Join(dir, "posts.jsonl"),
+	})
+	require.Error(t, err)
+}
+
+func TestIngestJSONLErrors(t *testing.T) {
+	config := &ingestConfig{
+		DefaultType: "post",
+		Types:       map[string]typeConfig{"post": {Fields: map[string]string{"title": "text"}}},
+	}
+
+	tt := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "invalid json",
+			input:   "{\"key\": \"id\", \"title\": \"A\"}\n{\n",
+			wantErr: "input:2: failed to parse document",
+		},
+		{
+			name:    "missing id",
+			input:   `{"title": "A"}`,
+			wantErr: "input:1: invalid key field: ID is required",
+		},
+		{
+			name:    "fractional id",
+			input:   `{"key": 1.5}`,
+			wantErr: "input:1: invalid key field: ID must be a string or an integer, got 1.5",
+		},
+		{
+			name:    "unknown type",
+			input:   `{"key": "a", "kind": "page"}`,
+			wantErr: `input:1: unknown type "page"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer, _ := newIngestIndexer(t, config)
+			options := ingestOptions{config: config, idField: "key", typeField: "kind"}
+			_, err := ingestJSONL(indexer, strings.NewReader(tc.input), "input", options)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
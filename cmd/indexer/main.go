@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+const usage = `Usage: indexer <command> [flags]
+
+Commands:
+  content   index a directory of Markdown and HTML files with front matter
+  ingest    index documents from JSON Lines or CSV files
+  crawl     index HTML pages listed in a sitemap
+
+Run "indexer <command> -h" to see flags of the command.
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("command is required")
+	}
+
+	switch args[0] {
+	case "content":
+		return runContent(args[1:])
+	case "ingest":
+		return runIngest(args[1:])
+	case "crawl":
+		return runCrawl(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stderr, usage)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	return errors.Errorf("unknown command %q", args[0])
+}
+
+// discardOnError discards the index if the command failed,
+// so neither the partial index nor temporary files are left behind.
+func discardOnError(indexer *search.Indexer, err *error) {
+	if *err == nil {
+		return
+	}
+	if discardErr := indexer.Discard(); discardErr != nil {
+		log.Printf("Failed to discard index: %v", discardErr)
+	}
+}
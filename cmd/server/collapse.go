@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/pkg/errors"
+)
+
+// maxInnerHits limits the `inner_hits` parameter.
+const maxInnerHits = resultSize
+
+// parseCollapse parses `collapse` parameter: the field to collapse hits by,
+// e.g. "ParentID" for translations of the same post. Field values must be stored:
+// explicitly mapped fields with the store option, others if dynamic fields are stored.
+func parseCollapse(m mapping.IndexMapping, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	fieldMappings := fieldMappings(m, value)
+	if len(fieldMappings) == 0 {
+		if !dynamicallyMapped(m, value) {
+			return "", errors.Errorf("unknown field %q", value)
+		}
+		if impl, ok := m.(*mapping.IndexMappingImpl); ok && !impl.StoreDynamic {
+			return "", errors.Errorf("field %q is not stored", value)
+		}
+		return value, nil
+	}
+	for _, fieldMapping := range fieldMappings {
+		if !fieldMapping.Store {
+			return "", errors.Errorf("field %q is not stored", value)
+		}
+	}
+	return value, nil
+}
+
+// parseInnerHits parses `inner_hits` parameter: the number of other hits
+// of each collapsed group to return with the top one.
+func parseInnerHits(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	innerHits, err := strconv.Atoi(value)
+	if err != nil || innerHits < 0 || innerHits > maxInnerHits {
+		return 0, errors.Errorf("inner_hits must be an integer from 0 to %d, got %q", maxInnerHits, value)
+	}
+	return innerHits, nil
+}
+
+// collapseGroups keeps the top group of groups with the same value of the field,
+// with up to innerHits other groups as its inner hits.
+// Groups without the value are not collapsed.
+func collapseGroups(groups []*hitGroup, field string, innerHits int) []*hitGroup {
+	result := []*hitGroup{}
+	top := map[string]*hitGroup{}
+	for _, group := range groups {
+		value, ok := group.fields[field]
+		if !ok {
+			result = append(result, group)
+			continue
+		}
+
+		key := fmt.Sprint(value)
+		first, ok := top[key]
+		if !ok {
+			top[key] = group
+			result = append(result, group)
+			continue
+		}
+		if len(first.inner) < innerHits {
+			first.inner = append(first.inner, group)
+		}
+	}
+	return result
+}
+
+// removeField removes the field from documents of groups and their inner hits.
+func removeField(groups []*hitGroup, field string) {
+	for _, group := range groups {
+		delete(group.fields, field)
+		removeField(group.inner, field)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type testTranslation struct {
+	ParentID string `indexer:"keyword"`
+	Title    string `indexer:"text"`
+	Body     string `indexer:"no_store"`
+}
+
+func (p testTranslation) Type() string {
+	return "Translation"
+}
+
+type testSeriesPost struct {
+	ParentID string
+	Title    string `indexer:"text"`
+}
+
+func (p testSeriesPost) Type() string {
+	return "SeriesPost"
+}
+
+func TestParseCollapse(t *testing.T) {
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = bleve.NewDocumentStaticMapping()
+	docMapping := bleve.NewDocumentStaticMapping()
+	docMapping.AddFieldMappingsAt("ParentID", bleve.NewKeywordFieldMapping())
+	body := bleve.NewTextFieldMapping()
+	body.Store = false
+	docMapping.AddFieldMappingsAt("Body", body)
+	m.AddDocumentMapping("Translation", docMapping)
+
+	got, err := parseCollapse(m, "ParentID")
+	require.NoError(t, err)
+	require.Equal(t, "ParentID", got)
+
+	_, err = parseCollapse(m, "Body")
+	require.EqualError(t, err, `field "Body" is not stored`)
+
+	_, err = parseCollapse(m, "Series")
+	require.EqualError(t, err, `unknown field "Series"`)
+
+	// fields without mapping are mapped dynamically and stored by default
+	m.DefaultMapping = bleve.NewDocumentMapping()
+	got, err = parseCollapse(m, "Series")
+	require.NoError(t, err)
+	require.Equal(t, "Series", got)
+
+	m.StoreDynamic = false
+	_, err = parseCollapse(m, "Series")
+	require.EqualError(t, err, `field "Series" is not stored`)
+}
+
+func TestParseInnerHits(t *testing.T) {
+	got, err := parseInnerHits("")
+	require.NoError(t, err)
+	require.Equal(t, 0, got)
+
+	got, err = parseInnerHits("3")
+	require.NoError(t, err)
+	require.Equal(t, 3, got)
+
+	for _, value := range []string{"-1", "11", "all"} {
+		_, err = parseInnerHits(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestHandleIndexCollapse(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"post-en": testTranslation{ParentID: "post", Title: "Kubernetes kubernetes operators"},
+		"post-de": testTranslation{ParentID: "post", Title: "Kubernetes Operatoren"},
+		"post-fr": testTranslation{ParentID: "post", Title: "Opérateurs Kubernetes pour les clusters"},
+		"other":   testTranslation{ParentID: "other", Title: "Kubernetes"},
+		"single":  testTranslation{Title: "Kubernetes kubernetes kubernetes"},
+	})
+
+	search := func(target, body string) []response {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var hits []response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hits))
+		return hits
+	}
+	ids := func(hits []response) []string {
+		result := []string{}
+		for _, hit := range hits {
+			result = append(result, hit.ID)
+		}
+		return result
+	}
+
+	require.Len(t, search("/?q=kubernetes&fields=Title", ""), 5)
+
+	// the top hit of each group, documents without the field are not collapsed
+	hits := search("/?q=kubernetes&fields=Title&collapse=ParentID", "")
+	require.ElementsMatch(t, []string{"single", "post-en", "other"}, ids(hits))
+	for _, hit := range hits {
+		require.Empty(t, hit.InnerHits)
+		require.Equal(t, map[string]interface{}{}, hit.Document, "collapse field is not returned unless requested")
+	}
+
+	hits = search("/?q=kubernetes&fields=Title&collapse=ParentID&inner_hits=1", `{"ParentID": true}`)
+	require.Len(t, hits, 3)
+	for _, hit := range hits {
+		if hit.ID != "post-en" {
+			require.Empty(t, hit.InnerHits)
+			continue
+		}
+		require.Equal(t, map[string]interface{}{"ParentID": "post"}, hit.Document)
+		require.Len(t, hit.InnerHits, 1)
+		require.Contains(t, []string{"post-de", "post-fr"}, hit.InnerHits[0].ID)
+		require.Equal(t, map[string]interface{}{"ParentID": "post"}, hit.InnerHits[0].Document)
+	}
+
+	hits = search("/?q=kubernetes&fields=Title&collapse=ParentID&inner_hits=5", "")
+	for _, hit := range hits {
+		if hit.ID == "post-en" {
+			require.ElementsMatch(t, []string{"post-de", "post-fr"}, ids(hit.InnerHits))
+		}
+	}
+
+	for target, wantErr := range map[string]string{
+		"/?q=kubernetes&collapse=Body":                  "error parsing collapse: field \"Body\" is not stored\n",
+		"/?q=kubernetes&inner_hits=2":                   "inner_hits can't be used without collapse\n",
+		"/?q=kubernetes&collapse=ParentID&inner_hits=x": "inner_hits must be an integer from 0 to 10, got \"x\"\n",
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusBadRequest, w.Code, target)
+		require.Equal(t, wantErr, w.Body.String(), target)
+	}
+}
+
+func TestHandleIndexCollapseDynamicField(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"post-en": testSeriesPost{ParentID: "post", Title: "Kubernetes kubernetes operators"},
+		"post-de": testSeriesPost{ParentID: "post", Title: "Kubernetes Operatoren"},
+		"other":   testSeriesPost{ParentID: "other", Title: "Kubernetes"},
+	})
+
+	hits := searchHits(t, srv, "/?q=kubernetes&fields=Title&collapse=ParentID")
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	require.ElementsMatch(t, []string{"post-en", "other"}, ids)
+}
@@ -6,28 +6,50 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	bleve "github.com/blevesearch/bleve/v2"
-	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/pkg/errors"
 )
 
+// searchResponse is the response with `did_you_mean=true`,
+// otherwise only hits are returned.
+type searchResponse struct {
+	Hits        []response `json:"hits"`
+	Suggestions []string   `json:"suggestions,omitempty"`
+}
+
 type response struct {
-	ID        string                  `json:"id"`
-	Score     float64                 `json:"score"`
-	Fragments search.FieldFragmentMap `json:"fragments"`
-	Document  interface{}             `json:"document,omitempty"`
+	ID         string              `json:"id"`
+	Score      float64             `json:"score"`
+	Anchor     string              `json:"anchor,omitempty"`
+	Section    string              `json:"section,omitempty"`
+	Fragments  map[string][]string `json:"fragments,omitempty"`
+	Highlights map[string][][]span `json:"highlights,omitempty"`
+	Locations  []fragment          `json:"locations,omitempty"`
+	Document   interface{}         `json:"document,omitempty"`
+	InnerHits  []response          `json:"inner_hits,omitempty"`
 }
 
+// fragment holds locations of matched terms in the field.
 type fragment struct {
 	Field     string                `json:"field"`
 	Locations map[string][]location `json:"locations"`
 }
 
+// location is a position of the term in the field value:
+// byte offsets and rune (character) offsets.
+// ArrayPositions point to the value in array fields.
 type location struct {
-	Start uint64 `json:"start"`
-	End   uint64 `json:"end"`
+	Start          uint64   `json:"start"`
+	End            uint64   `json:"end"`
+	RuneStart      uint64   `json:"rune_start"`
+	RuneEnd        uint64   `json:"rune_end"`
+	ArrayPositions []uint64 `json:"array_positions,omitempty"`
 }
 
 func (s *server) handleIndex() http.HandlerFunc {
@@ -37,11 +59,128 @@ func (s *server) handleIndex() http.HandlerFunc {
 
 		if lang == "" {
 			lang = s.defaultLanguage
+			if s.detector != nil {
+				if detected := s.detector.Detect(queryString); detected != "" {
+					lang = detected
+				}
+			}
+		}
+		languages, err := parseLanguages(lang, s.indexLanguages, s.cache, s.analyzers)
+		if err != nil {
+			http.Error(w, "error parsing lang: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		analyzers := make([]*analysis.Analyzer, len(languages))
+		for i, language := range languages {
+			analyzers[i], err = s.cache.AnalyzerNamed(language.name)
+			if err != nil {
+				log.Printf("Error getting analyzer: %v", err)
+				http.Error(w, "error getting analyzer", http.StatusInternalServerError)
+				return
+			}
+			if s.synonyms != nil {
+				analyzers[i] = withSynonyms(analyzers[i], s.synonyms.get())
+			}
+		}
+
+		fuzziness, err := parseFuzziness(r.URL.Query().Get("fuzziness"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		prefix, err := parsePrefixLength(r.URL.Query().Get("prefix_length"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		slop, err := parseSlop(r.URL.Query().Get("slop"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		operator, err := parseOperator(r.URL.Query().Get("operator"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		minimumShouldMatch, err := parseMinimumShouldMatch(r.URL.Query().Get("minimum_should_match"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if operator == operatorAnd && minimumShouldMatch.value != 0 {
+			http.Error(w, "minimum_should_match can't be used with operator=and", http.StatusBadRequest)
+			return
 		}
-		analyser, err := s.cache.AnalyzerNamed(lang)
+
+		options := queryOptions{
+			fuzziness:          fuzziness,
+			prefix:             prefix,
+			slop:               slop,
+			operator:           operator,
+			minimumShouldMatch: minimumShouldMatch,
+		}
+
+		searchFields, err := s.parseSearchFields(r.URL.Query().Get("fields"))
+		if err != nil {
+			http.Error(w, "error parsing fields: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		highlight, err := parseHighlight(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		includeLocations, err := parseBool(r.URL.Query().Get("locations"))
 		if err != nil {
-			log.Printf("Error getting analyzer: %v", err)
-			http.Error(w, "error getting analyzer", http.StatusInternalServerError)
+			http.Error(w, "error parsing locations: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		didYouMean, err := parseBool(r.URL.Query().Get("did_you_mean"))
+		if err != nil {
+			http.Error(w, "error parsing did_you_mean: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if didYouMean {
+			suggestFields, err := s.suggestFields()
+			if err != nil {
+				log.Printf("Error getting suggestion fields: %v", err)
+				http.Error(w, "error getting suggestion fields", http.StatusInternalServerError)
+				return
+			}
+			if len(suggestFields) == 0 {
+				http.Error(w, "error parsing did_you_mean: index has no suggest fields", http.StatusBadRequest)
+				return
+			}
+		}
+
+		sortOrder, err := parseSort(s.index.Mapping(), r.URL.Query().Get("sort"))
+		if err != nil {
+			http.Error(w, "error parsing sort: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		collapse, err := parseCollapse(s.index.Mapping(), r.URL.Query().Get("collapse"))
+		if err != nil {
+			http.Error(w, "error parsing collapse: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		innerHits, err := parseInnerHits(r.URL.Query().Get("inner_hits"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if innerHits > 0 && collapse == "" {
+			http.Error(w, "inner_hits can't be used without collapse", http.StatusBadRequest)
 			return
 		}
 
@@ -59,17 +198,39 @@ func (s *server) handleIndex() http.HandlerFunc {
 			return
 		}
 
-		ts := analyser.Analyze([]byte(queryString))
-		queryString = ""
-		for _, token := range ts {
-			queryString += fmt.Sprintf("%s ", token.Term)
+		// values of the collapse field are needed to collapse hits
+		requestFields := fields
+		if collapse != "" && !contains(fields, collapse) {
+			requestFields = append(fields[:len(fields):len(fields)], collapse)
+		}
+
+		searchQuery := fieldsQuery(searchFields, languages, analyzers, queryString, options)
+		if types := parseList(r.URL.Query().Get("type")); len(types) > 0 {
+			searchQuery = bleve.NewConjunctionQuery(searchQuery, typeQuery(s.index.Mapping(), types))
+		}
+
+		hasSections, err := s.hasSections()
+		if err != nil {
+			log.Printf("Error checking sections: %v", err)
+			http.Error(w, "error checking sections", http.StatusInternalServerError)
+			return
+		}
+		if hasSections && sortOrder != nil {
+			searchQuery = withoutSections(searchQuery)
+		}
+		size := resultSize
+		if hasSections || collapse != "" {
+			size = searchSize
 		}
 
-		query := bleve.NewMatchQuery(queryString)
-		search := bleve.NewSearchRequest(query)
-		search.Highlight = bleve.NewHighlight()
-		search.IncludeLocations = true
-		search.Fields = fields
+		search := bleve.NewSearchRequest(searchQuery)
+		// locations of matches are needed for highlighting
+		search.IncludeLocations = includeLocations || highlight != nil
+		search.Fields = sectionFields(requestFields)
+		search.Size = size
+		if sortOrder != nil {
+			search.SortBy(sortOrder)
+		}
 		log.Printf("Searching fields %s", fields)
 
 		searchResults, err := s.index.Search(search)
@@ -81,7 +242,63 @@ func (s *server) handleIndex() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("X-Took", fmt.Sprintf("%v", searchResults.Took))
 
-		resp := formatResponse(searchResults)
+		s.mergeLocalizedLocations(searchResults.Hits)
+		groups := groupSections(searchResults.Hits)
+		// only the first searchSize hits are collapsed: results may have
+		// fewer than resultSize groups and inner hits of lower hits are missing
+		if collapse != "" {
+			if err := s.loadDocuments(groups, requestFields); err != nil {
+				log.Printf("Error loading documents: %v", err)
+				http.Error(w, "error loading documents", http.StatusInternalServerError)
+				return
+			}
+			groups = collapseGroups(groups, collapse, innerHits)
+		}
+		if len(groups) > resultSize {
+			groups = groups[:resultSize]
+		}
+		if err := s.loadDocuments(groups, fields); err != nil {
+			log.Printf("Error loading documents: %v", err)
+			http.Error(w, "error loading documents", http.StatusInternalServerError)
+			return
+		}
+		if len(requestFields) > len(fields) {
+			removeField(groups, collapse)
+		}
+
+		hits := formatResponse(groups)
+		if highlight != nil {
+			for i, group := range groups {
+				hits[i].Fragments, hits[i].Highlights, err = s.highlight(highlight, group.hit)
+				if err != nil {
+					log.Printf("Error highlighting: %v", err)
+					http.Error(w, "error highlighting", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+		if includeLocations {
+			for i, group := range groups {
+				hits[i].Locations, err = s.locations(group.hit)
+				if err != nil {
+					log.Printf("Error getting locations: %v", err)
+					http.Error(w, "error getting locations", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+		var resp interface{} = hits
+		if didYouMean {
+			withSuggestions := searchResponse{Hits: hits}
+			if searchResults.Total == 0 {
+				withSuggestions.Suggestions, err = s.didYouMean(r.URL.Query().Get("q"))
+				if err != nil {
+					log.Printf("Error getting spelling suggestions: %v", err)
+				}
+			}
+			resp = withSuggestions
+		}
+
 		encoder := json.NewEncoder(w)
 		encoder.SetIndent("", "  ")
 		encoder.SetEscapeHTML(false)
@@ -92,6 +309,71 @@ func (s *server) handleIndex() http.HandlerFunc {
 	}
 }
 
+// parseSort converts the comma-separated `sort` parameter, e.g. "-Date,Title,_score",
+// into bleve sort order. Fields indexed without doc values can't be sorted on.
+func parseSort(m mapping.IndexMapping, value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var order []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		name := strings.TrimPrefix(field, "-")
+		if name == "" {
+			return nil, errors.New("empty sort field")
+		}
+
+		if name != "_score" && name != "_id" {
+			for _, fieldMapping := range fieldMappings(m, name) {
+				if !fieldMapping.Index || !fieldMapping.DocValues {
+					return nil, errors.Errorf("field %q is not sortable", name)
+				}
+			}
+		}
+
+		order = append(order, field)
+	}
+	return order, nil
+}
+
+// parseBool parses boolean parameter, empty value is false.
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// parseList splits comma-separated parameter value, skipping empty items.
+func parseList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// typeQuery matches documents of any of the given types.
+// Indexer stores document type in the type field of the index mapping.
+func typeQuery(m mapping.IndexMapping, types []string) query.Query {
+	typeField := "_type"
+	if impl, ok := m.(*mapping.IndexMappingImpl); ok {
+		typeField = impl.TypeField
+	}
+
+	disjunction := bleve.NewDisjunctionQuery()
+	for _, docType := range types {
+		termQuery := bleve.NewTermQuery(docType)
+		termQuery.SetField(typeField)
+		disjunction.AddQuery(termQuery)
+	}
+	return disjunction
+}
+
 func parseFields(body []byte) ([]string, error) {
 	if len(body) == 0 {
 		return nil, nil
@@ -136,14 +418,18 @@ func extractFields(field string, value interface{}) []string {
 	return nil
 }
 
-func formatResponse(searchResults *bleve.SearchResult) []response {
-	var resp []response
-	for _, hit := range searchResults.Hits {
+// formatResponse formats grouped hits, highlights and locations
+// are of the best hit of each group.
+func formatResponse(groups []*hitGroup) []response {
+	resp := []response{}
+	for _, group := range groups {
 		resp = append(resp, response{
-			ID:        hit.ID,
-			Score:     hit.Score,
-			Fragments: hit.Fragments,
-			Document:  buildDocument(hit.Fields),
+			ID:        group.id,
+			Score:     group.score,
+			Anchor:    group.anchor,
+			Section:   group.section,
+			Document:  buildDocument(group.fields),
+			InnerHits: formatResponse(group.inner),
 		})
 	}
 	return resp
@@ -1,10 +1,21 @@
 package main
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
 )
 
 func TestParseFields(t *testing.T) {
@@ -117,3 +128,232 @@ func TestBuildDocument(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSort(t *testing.T) {
+	docMapping := mapping.NewDocumentMapping()
+	title := mapping.NewTextFieldMapping()
+	body := mapping.NewTextFieldMapping()
+	body.DocValues = false
+	docMapping.AddFieldMappingsAt("Title", title)
+	docMapping.AddFieldMappingsAt("Body", body)
+	docMapping.AddFieldMappingsAt("Date", mapping.NewDateTimeFieldMapping())
+
+	indexMapping := mapping.NewIndexMapping()
+	indexMapping.AddDocumentMapping("post", docMapping)
+
+	tt := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "fields and score",
+			value: "-Date,Title,_score",
+			want:  []string{"-Date", "Title", "_score"},
+		},
+		{
+			name:  "dynamic field",
+			value: "Unknown",
+			want:  []string{"Unknown"},
+		},
+		{
+			name:    "not sortable",
+			value:   "Title,-Body",
+			wantErr: `field "Body" is not sortable`,
+		},
+		{
+			name:    "empty field",
+			value:   "Title,-",
+			wantErr: "empty sort field",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseSort(indexMapping, tc.value)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+type testPost struct {
+	Title string `indexer:"text,sortable"`
+	Date  string `indexer:"date"`
+}
+
+func (p testPost) Type() string {
+	return "Post"
+}
+
+type testPage struct {
+	Title string `indexer:"text"`
+}
+
+func (p testPage) Type() string {
+	return "Page"
+}
+
+// newTestServer builds an index with given documents using search.Indexer
+// and returns a server which serves it.
+func newTestServer(t *testing.T, docs map[string]interface{}) *server {
+	return newTestServerWithAnalyzers(t, nil, docs)
+}
+
+// newTestServerWithAnalyzers is newTestServer with custom analyzers
+// registered in the indexer and loaded by the server from the config file.
+func newTestServerWithAnalyzers(t *testing.T, analyzers []search.AnalyzerConfig, docs map[string]interface{}) *server {
+	path := filepath.Join(t.TempDir(), "index")
+
+	indexer, err := search.NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	for _, analyzer := range analyzers {
+		require.NoError(t, indexer.RegisterAnalyzer(analyzer), "failed to register analyzer")
+	}
+
+	// all types must be registered before the first document is indexed
+	for _, doc := range docs {
+		require.NoError(t, indexer.RegisterType(doc, "en"), "failed to register type")
+	}
+	for id, doc := range docs {
+		require.NoError(t, indexer.Index(id, doc), "failed to index")
+	}
+	require.NoError(t, indexer.Close(), "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+	t.Cleanup(func() { index.Close() })
+
+	srv := &server{
+		router:          chi.NewRouter(),
+		index:           index,
+		defaultLanguage: "en",
+		cache:           registry.NewCache(),
+	}
+	if len(analyzers) > 0 {
+		analyzersPath := filepath.Join(t.TempDir(), "analyzers.json")
+		b, err := json.Marshal(search.AnalyzersConfig{Analyzers: analyzers})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(analyzersPath, b, 0644))
+		require.NoError(t, srv.loadAnalyzers(analyzersPath), "failed to load analyzers")
+	}
+	srv.routes()
+	return srv
+}
+
+// searchIDs sends the search request and returns IDs of found documents.
+func searchIDs(t *testing.T, srv *server, target string) []string {
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var hits []response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hits))
+
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestHandleIndexType(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"post":  testPost{Title: "Kubernetes"},
+		"page":  testPage{Title: "Kubernetes"},
+		"other": testPage{Title: "Docker"},
+	})
+
+	got := searchIDs(t, srv, "/?q=kubernetes&type=Post")
+	require.Equal(t, []string{"post"}, got)
+
+	got = searchIDs(t, srv, "/?q=kubernetes&type=Page")
+	require.Equal(t, []string{"page"}, got)
+
+	got = searchIDs(t, srv, "/?q=kubernetes&type=Post,Page")
+	sort.Strings(got)
+	require.Equal(t, []string{"page", "post"}, got)
+
+	got = searchIDs(t, srv, "/?q=kubernetes&type=Unknown")
+	require.Equal(t, []string{}, got)
+}
+
+func TestHandleIndexSort(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"one": testPost{Title: "Bravo kubernetes", Date: "2006-01-01"},
+		"two": testPost{Title: "Alpha kubernetes", Date: "2006-01-02"},
+	})
+
+	require.Equal(t, []string{"two", "one"}, searchIDs(t, srv, "/?q=kubernetes&sort=-Date"))
+	require.Equal(t, []string{"one", "two"}, searchIDs(t, srv, "/?q=kubernetes&sort=Date"))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=kubernetes&sort=-", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleIndexFuzziness(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"exact": testPage{Title: "serch"},
+		"typo":  testPage{Title: "search"},
+		"other": testPage{Title: "docker"},
+	})
+
+	require.Equal(t, []string{"exact"}, searchIDs(t, srv, "/?q=serch"))
+	require.Equal(t, []string{"exact", "typo"}, searchIDs(t, srv, "/?q=serch&fuzziness=auto"))
+	require.Equal(t, []string{"exact", "typo"}, searchIDs(t, srv, "/?q=serch&fuzziness=1"))
+	require.Equal(t, []string{"exact"}, searchIDs(t, srv, "/?q=serch&fuzziness=1&prefix_length=20"))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=serch&fuzziness=5", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleIndexSuggestions(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"one": testArticle{Title: "Kubernetes operators"},
+		"two": testArticle{Title: "Running Kubernetes"},
+	})
+
+	suggestions := func(target string) []string {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp searchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Suggestions
+	}
+
+	require.Equal(t, []string{"kubernetes operators"}, suggestions("/?q=kubernetis+operatrs&did_you_mean=true"))
+	require.Nil(t, suggestions("/?q=kubernetes&did_you_mean=true"))
+	require.Nil(t, suggestions("/?q=unrelated&did_you_mean=true"))
+
+	// without did_you_mean, the response is the array of hits
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=kubernetis+operatrs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, "[]", w.Body.String())
+}
+
+func TestHandleIndexSuggestionsWithoutSuggestFields(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"one": testPage{Title: "Fluffy kittens"},
+	})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=fluffu&did_you_mean=true", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, "error parsing did_you_mean: index has no suggest fields\n", w.Body.String())
+}
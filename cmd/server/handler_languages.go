@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chuhlomin/search"
+)
+
+type languageResponse struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Custom bool   `json:"custom,omitempty"`
+}
+
+// handleLanguages lists supported languages and custom analyzers,
+// the values `lang` parameter accepts.
+
+func (s *server) handleLanguages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := []languageResponse{}
+		for _, code := range search.SupportedLanguages() {
+			resp = append(resp, languageResponse{
+				Code: code,
+				Name: search.LanguageName(code),
+			})
+		}
+		for _, name := range s.analyzers {
+			resp = append(resp, languageResponse{Code: name, Name: name, Custom: true})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
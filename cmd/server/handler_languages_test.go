@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+func TestHandleLanguages(t *testing.T) {
+	srv := newTestServerWithAnalyzers(t,
+		[]search.AnalyzerConfig{{Name: "docs", Language: "en"}},
+		map[string]interface{}{
+			"page": testPage{Title: "Kubernetes"},
+		},
+	)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/languages", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp []languageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Contains(t, resp, languageResponse{Code: "en", Name: "English"})
+	require.Contains(t, resp, languageResponse{Code: "ru", Name: "Russian"})
+	require.Contains(t, resp, languageResponse{Code: "docs", Name: "docs", Custom: true})
+}
+
+func TestHandleIndexUnsupportedLanguage(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"page": testPage{Title: "Kubernetes"},
+	})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=kubernetes&lang=xx", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.True(t, strings.Contains(w.Body.String(), "supported languages: ar, cjk"), w.Body.String())
+}
+
+func TestHandleIndexBuiltInAnalyzer(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"page": testPage{Title: "Docker"},
+	})
+
+	require.Equal(t, []string{"page"}, searchIDs(t, srv, "/?q=Docker&lang=standard"))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+const defaultSuggestSize = 10
+
+type suggestion struct {
+	term  string
+	count uint64
+}
+
+func (s *server) handleSuggest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		size, err := parseSize(r.URL.Query().Get("size"), defaultSuggestSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// complete the last word, keeping the rest of the query as is
+		queryString := strings.ToLower(r.URL.Query().Get("q"))
+		head, prefix := "", queryString
+		if i := strings.LastIndexAny(queryString, " \t"); i != -1 {
+			head, prefix = queryString[:i+1], queryString[i+1:]
+		}
+
+		suggestions, err := s.suggest(prefix, size)
+		if err != nil {
+			log.Printf("Error getting suggestions: %v", err)
+			http.Error(w, "error getting suggestions", http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]string, 0, len(suggestions))
+		for _, suggestion := range suggestions {
+			resp = append(resp, head+suggestion.term)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// suggest returns up to size most frequent terms starting with prefix
+// from term dictionaries of all suggestion fields.
+func (s *server) suggest(prefix string, size int) ([]suggestion, error) {
+	if prefix == "" {
+		return nil, nil
+	}
+
+	fields, err := s.suggestFields()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]uint64{}
+	for _, field := range fields {
+		dict, err := s.index.FieldDictPrefix(field, []byte(prefix))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s dictionary", field)
+		}
+		if err := readDict(dict, counts); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s dictionary", field)
+		}
+	}
+
+	suggestions := make([]suggestion, 0, len(counts))
+	for term, count := range counts {
+		suggestions = append(suggestions, suggestion{term: term, count: count})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].count != suggestions[j].count {
+			return suggestions[i].count > suggestions[j].count
+		}
+		return suggestions[i].term < suggestions[j].term
+	})
+
+	if len(suggestions) > size {
+		suggestions = suggestions[:size]
+	}
+	return suggestions, nil
+}
+
+// suggestFields returns names of all suggestion fields in the index.
+func (s *server) suggestFields() ([]string, error) {
+	fields, err := s.index.Fields()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get index fields")
+	}
+
+	var result []string
+	for _, field := range fields {
+		if strings.HasSuffix(field, search.SuggestFieldSuffix) {
+			result = append(result, field)
+		}
+	}
+	return result, nil
+}
+
+// readDict adds term counts from the dictionary to counts and closes it.
+func readDict(dict index.FieldDict, counts map[string]uint64) error {
+	defer dict.Close()
+
+	for {
+		entry, err := dict.Next()
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return nil
+		}
+		counts[entry.Term] += entry.Count
+	}
+}
+
+// parseSize parses positive integer `size` parameter.
+func parseSize(value string, defaultSize int) (int, error) {
+	if value == "" {
+		return defaultSize, nil
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return 0, errors.Errorf("size must be a positive integer, got %q", value)
+	}
+	return size, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testArticle struct {
+	Title string `indexer:"text,suggest"`
+	Body  string `indexer:"text"`
+}
+
+func (a testArticle) Type() string {
+	return "Article"
+}
+
+func TestHandleSuggest(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"one":   testArticle{Title: "Kubernetes operators", Body: "kubeadm"},
+		"two":   testArticle{Title: "Kubernetes and kubectl"},
+		"three": testArticle{Title: "Running Docker"},
+	})
+
+	suggest := func(target string) []string {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp []string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	require.Equal(t, []string{"kubernetes", "kubectl"}, suggest("/suggest?q=kube"))
+	require.Equal(t, []string{"kubernetes"}, suggest("/suggest?q=Kube&size=1"))
+	require.Equal(t, []string{"how to running"}, suggest("/suggest?q=how+to+run"))
+	require.Equal(t, []string{}, suggest("/suggest?q=kube+"))
+	require.Equal(t, []string{}, suggest("/suggest?q=unknown"))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/suggest?q=kube&size=0", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/ansi"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	simpleFragmenter "github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
+	"github.com/pkg/errors"
+)
+
+const (
+	highlightHTML  = "html"
+	highlightANSI  = "ansi"
+	highlightPlain = "plain"
+)
+
+// defaultFragmentSize is the size of fragments in bytes, same as bleve uses.
+const defaultFragmentSize = 200
+
+// fragmentSeparator marks fragments which don't start or end with the field value.
+const fragmentSeparator = "…"
+
+// highlightOptions are parameters of highlighting.
+type highlightOptions struct {
+	style        string
+	preTag       string
+	postTag      string
+	fragmentSize int
+	fragments    int
+	fields       []string
+}
+
+// span is a highlighted part of a fragment, byte offsets.
+type span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// parseHighlight parses highlighting parameters:
+//   - `highlight`: style, "html" (default, the text is HTML-escaped), "ansi"
+//     or "plain" (the text as is, with offsets of matches),
+//   - `pre_tag` and `post_tag`: tags around matches for html style, `<mark>` by default,
+//     only simple formatting tags are allowed,
+//   - `fragment_size`: the size of fragments in bytes, 200 by default,
+//   - `fragments`: the number of fragments per field, 1 by default,
+//   - `highlight_fields`: comma-separated fields to highlight, fields with matches by default.
+//
+// Highlighting is off (nil options) if none of the parameters is set.
+func parseHighlight(values url.Values) (*highlightOptions, error) {
+	requested := false
+	for _, param := range []string{"highlight", "pre_tag", "post_tag", "fragment_size", "fragments", "highlight_fields"} {
+		if values.Get(param) != "" {
+			requested = true
+		}
+	}
+	if !requested {
+		return nil, nil
+	}
+
+	options := &highlightOptions{
+		style:        values.Get("highlight"),
+		preTag:       values.Get("pre_tag"),
+		postTag:      values.Get("post_tag"),
+		fragmentSize: defaultFragmentSize,
+		fragments:    1,
+		fields:       parseList(values.Get("highlight_fields")),
+	}
+
+	switch options.style {
+	case "":
+		options.style = highlightHTML
+	case highlightHTML, highlightANSI, highlightPlain:
+	default:
+		return nil, errors.Errorf("highlight must be one of html, ansi, plain, got %q", options.style)
+	}
+
+	if options.style != highlightHTML && (options.preTag != "" || options.postTag != "") {
+		return nil, errors.Errorf("pre_tag and post_tag can't be used with %s highlight", options.style)
+	}
+	if options.preTag == "" && options.postTag == "" {
+		options.preTag, options.postTag = "<mark>", "</mark>"
+	}
+	if err := validateTags(options.preTag, options.postTag); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if value := values.Get("fragment_size"); value != "" {
+		options.fragmentSize, err = strconv.Atoi(value)
+		if err != nil || options.fragmentSize <= 0 {
+			return nil, errors.Errorf("fragment_size must be a positive integer, got %q", value)
+		}
+	}
+	if value := values.Get("fragments"); value != "" {
+		options.fragments, err = strconv.Atoi(value)
+		if err != nil || options.fragments <= 0 {
+			return nil, errors.Errorf("fragments must be a positive integer, got %q", value)
+		}
+	}
+	return options, nil
+}
+
+// preTagRegexp matches opening tags allowed around matches:
+// a formatting element with an optional class attribute.
+var preTagRegexp = regexp.MustCompile(`^<(mark|em|strong|b|i|u|span)( class="[\w\- ]*")?>$`)
+
+// validateTags ensures that tags around matches are a pair of safe tags, so
+// fragments never contain markup other than highlighting: the rest of the text
+// is HTML-escaped by the formatter.
+func validateTags(preTag, postTag string) error {
+	match := preTagRegexp.FindStringSubmatch(preTag)
+	if match == nil {
+		return errors.Errorf(
+			"pre_tag must be one of mark, em, strong, b, i, u, span tags with optional class, got %q",
+			preTag,
+		)
+	}
+	if want := "</" + match[1] + ">"; postTag != want {
+		return errors.Errorf("post_tag must be %q to close pre_tag, got %q", want, postTag)
+	}
+	return nil
+}
+
+// highlight returns the best fragments of the hit fields with matches highlighted
+// and, for plain style, offsets of matches in fragments.
+// Hit must have locations of matches.
+func (s *server) highlight(options *highlightOptions, hit *search.DocumentMatch) (map[string][]string, map[string][][]span, error) {
+	doc, err := s.index.Document(hit.ID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to load document %s", hit.ID)
+	}
+	if doc == nil {
+		return nil, nil, nil
+	}
+
+	var formatter highlight.FragmentFormatter
+	plain := &plainFormatter{}
+	switch options.style {
+	case highlightHTML:
+		formatter = html.NewFragmentFormatter(options.preTag, options.postTag)
+	case highlightANSI:
+		formatter = ansi.NewFragmentFormatter(ansi.DefaultAnsiHighlight)
+	case highlightPlain:
+		formatter = plain
+	}
+	highlighter := simpleHighlighter.NewHighlighter(
+		simpleFragmenter.NewFragmenter(options.fragmentSize),
+		formatter,
+		fragmentSeparator,
+	)
+
+	fields := options.fields
+	if len(fields) == 0 {
+		for field := range hit.Locations {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+	}
+
+	fragments := map[string][]string{}
+	var spans map[string][][]span
+	if options.style == highlightPlain {
+		spans = map[string][][]span{}
+	}
+	for _, field := range fields {
+		plain.spans = nil
+		if fieldFragments := highlighter.BestFragmentsInField(hit, doc, field, options.fragments); len(fieldFragments) > 0 {
+			fragments[field] = fieldFragments
+			if spans != nil {
+				spans[field] = plain.spans
+			}
+		}
+	}
+	return fragments, spans, nil
+}
+
+// plainFormatter formats fragments as plain text
+// and records offsets of matches in each of them.
+type plainFormatter struct {
+	spans [][]span
+}
+
+func (f *plainFormatter) Format(fragment *highlight.Fragment, orderedTermLocations highlight.TermLocations) string {
+	// highlighter prepends separator to fragments not at the start of the value
+	offset := 0
+	if fragment.Start != 0 {
+		offset = len(fragmentSeparator)
+	}
+
+	spans := []span{}
+	curr := fragment.Start
+	for _, termLocation := range orderedTermLocations {
+		if termLocation == nil || !termLocation.ArrayPositions.Equals(fragment.ArrayPositions) {
+			continue
+		}
+		if termLocation.Start < curr {
+			continue
+		}
+		if termLocation.End > fragment.End {
+			break
+		}
+
+		spans = append(spans, span{
+			Start: offset + termLocation.Start - fragment.Start,
+			End:   offset + termLocation.End - fragment.Start,
+		})
+		curr = termLocation.End
+	}
+	f.spans = append(f.spans, spans)
+
+	return string(fragment.Orig[fragment.Start:fragment.End])
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// searchHits sends the search request and returns found documents.
+func searchHits(t *testing.T, srv *server, target string) []response {
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var hits []response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hits))
+	return hits
+}
+
+func TestParseHighlight(t *testing.T) {
+	tt := []struct {
+		name    string
+		query   string
+		want    *highlightOptions
+		wantErr bool
+	}{
+		{
+			name:  "off",
+			query: "q=kubernetes",
+		},
+		{
+			name:  "default",
+			query: "highlight=html",
+			want: &highlightOptions{
+				style:        highlightHTML,
+				preTag:       "<mark>",
+				postTag:      "</mark>",
+				fragmentSize: defaultFragmentSize,
+				fragments:    1,
+			},
+		},
+		{
+			name:  "implied by other parameters",
+			query: "pre_tag=<b>&post_tag=</b>&fragment_size=50&fragments=3&highlight_fields=Title,Body",
+			want: &highlightOptions{
+				style:        highlightHTML,
+				preTag:       "<b>",
+				postTag:      "</b>",
+				fragmentSize: 50,
+				fragments:    3,
+				fields:       []string{"Title", "Body"},
+			},
+		},
+		{
+			name:  "tag with class",
+			query: `pre_tag=<span class="hl">&post_tag=</span>`,
+			want: &highlightOptions{
+				style:        highlightHTML,
+				preTag:       `<span class="hl">`,
+				postTag:      "</span>",
+				fragmentSize: defaultFragmentSize,
+				fragments:    1,
+			},
+		},
+		{
+			name:    "unsafe tag",
+			query:   "pre_tag=<script>&post_tag=</script>",
+			wantErr: true,
+		},
+		{
+			name:    "unsafe attribute",
+			query:   `pre_tag=<span onclick="alert(1)">&post_tag=</span>`,
+			wantErr: true,
+		},
+		{
+			name:    "mismatched tags",
+			query:   "pre_tag=<b>&post_tag=</i>",
+			wantErr: true,
+		},
+		{
+			name:    "missing post tag",
+			query:   "pre_tag=<b>",
+			wantErr: true,
+		},
+		{
+			name:    "unknown style",
+			query:   "highlight=bold",
+			wantErr: true,
+		},
+		{
+			name:    "tags with plain style",
+			query:   "highlight=plain&pre_tag=<b>",
+			wantErr: true,
+		},
+		{
+			name:    "invalid fragment size",
+			query:   "fragment_size=0",
+			wantErr: true,
+		},
+		{
+			name:    "invalid fragments",
+			query:   "fragments=many",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			got, err := parseHighlight(values)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestHandleIndexHighlight(t *testing.T) {
+	body := strings.Repeat("Lorem ipsum dolor sit amet. ", 10) +
+		"Kubernetes runs containers. " +
+		strings.Repeat("Lorem ipsum dolor sit amet. ", 10) +
+		"Kubernetes schedules pods."
+	srv := newTestServer(t, map[string]interface{}{
+		"post": testBlogPost{Title: "Kubernetes operators", Body: body},
+	})
+
+	hits := searchHits(t, srv, "/?q=kubernetes")
+	require.Len(t, hits, 1)
+	require.Nil(t, hits[0].Fragments)
+
+	hits = searchHits(t, srv, "/?q=kubernetes&highlight=html")
+	require.Equal(t, []string{"<mark>Kubernetes</mark> operators"}, hits[0].Fragments["Title"])
+	require.Len(t, hits[0].Fragments["Body"], 1)
+	require.Nil(t, hits[0].Highlights)
+
+	hits = searchHits(t, srv, "/?q=kubernetes&pre_tag=<em>&post_tag=</em>&fragments=2&fragment_size=40&highlight_fields=Body")
+	require.NotContains(t, hits[0].Fragments, "Title")
+	require.Len(t, hits[0].Fragments["Body"], 2)
+	for _, fragment := range hits[0].Fragments["Body"] {
+		require.Contains(t, fragment, "<em>Kubernetes</em>")
+		require.LessOrEqual(t, len(fragment), 40+2*len(fragmentSeparator)+len("<em></em>"))
+	}
+
+	hits = searchHits(t, srv, "/?q=kubernetes+operators&highlight=plain&highlight_fields=Title")
+	require.Equal(t, []string{"Kubernetes operators"}, hits[0].Fragments["Title"])
+	require.Equal(t, [][]span{{{Start: 0, End: 10}, {Start: 11, End: 20}}}, hits[0].Highlights["Title"])
+
+	hits = searchHits(t, srv, "/?q=schedules&highlight=plain&fragment_size=40")
+	fragment := hits[0].Fragments["Body"][0]
+	highlight := hits[0].Highlights["Body"][0][0]
+	require.True(t, strings.HasPrefix(fragment, fragmentSeparator))
+	require.Equal(t, "schedules", fragment[highlight.Start:highlight.End])
+}
+
+func TestHandleIndexHighlightEscaping(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"post": testBlogPost{Title: `<script>alert("kubernetes")</script> & <b>kubernetes</b>`},
+	})
+
+	hits := searchHits(t, srv, "/?q=kubernetes&highlight=html&highlight_fields=Title")
+	require.Equal(t, []string{
+		`&lt;script&gt;alert(&#34;<mark>kubernetes</mark>&#34;)&lt;/script&gt; &amp; &lt;b&gt;<mark>kubernetes</mark>&lt;/b&gt;`,
+	}, hits[0].Fragments["Title"])
+
+	hits = searchHits(t, srv, "/?q=kubernetes&highlight=plain&highlight_fields=Title")
+	require.Equal(t, []string{`<script>alert("kubernetes")</script> & <b>kubernetes</b>`}, hits[0].Fragments["Title"])
+	require.Equal(t, [][]span{{{Start: 15, End: 25}, {Start: 42, End: 52}}}, hits[0].Highlights["Title"])
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=kubernetes&pre_tag=%3Cscript%3E&post_tag=%3C/script%3E", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// allLanguages is a `lang` parameter value to search in all languages
+// used by the text fields of the index.
+const allLanguages = "all"
+
+// language is a language to analyze query with and its boost.
+type language struct {
+	name  string
+	boost float64
+}
+
+// parseLanguages parses `lang` parameter: comma-separated list of languages,
+// custom or bleve built-in analyzers with optional boosts, e.g. "en^2,ru", or "all".
+func parseLanguages(value string, indexLanguages func() ([]string, error), cache *registry.Cache, customAnalyzers []string) ([]language, error) {
+	if value == allLanguages {
+		names, err := indexLanguages()
+		if err != nil {
+			return nil, err
+		}
+
+		var result []language
+		for _, name := range names {
+			result = append(result, language{name: name, boost: 1})
+		}
+		if len(result) == 0 {
+			return nil, errors.New("index has no text fields with languages")
+		}
+		return result, nil
+	}
+
+	var result []language
+	for _, item := range parseList(value) {
+		name, boost, err := parseBoost(item)
+		if err != nil {
+			return nil, err
+		}
+		if !search.IsLanguageSupported(name) && !contains(customAnalyzers, name) && !isAnalyzer(cache, name) {
+			return nil, errors.Errorf(
+				"unsupported language %q, supported languages: %s",
+				name,
+				strings.Join(append(search.SupportedLanguages(), customAnalyzers...), ", "),
+			)
+		}
+		result = append(result, language{name: name, boost: boost})
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("no languages specified")
+	}
+	return result, nil
+}
+
+// isAnalyzer reports whether the cache has the analyzer, e.g. bleve's "standard".
+func isAnalyzer(cache *registry.Cache, name string) bool {
+	_, err := cache.AnalyzerNamed(name)
+	return err == nil
+}
+
+// loadAnalyzers defines custom analyzers from the config file,
+// the same the indexer was configured with, so queries are analyzed
+// the same way as documents.
+func (s *server) loadAnalyzers(path string) error {
+	configs, err := search.LoadAnalyzers(path)
+	if err != nil {
+		return err
+	}
+
+	if err := search.DefineAnalyzers(s.cache, configs...); err != nil {
+		return err
+	}
+	for _, config := range configs {
+		s.analyzers = append(s.analyzers, config.Name)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// indexLanguages returns sorted languages used in the index: languages of text fields
+// in the index mapping and per-document languages stored by the indexer.
+func (s *server) indexLanguages() ([]string, error) {
+	languages := map[string]bool{}
+	for _, analyzer := range indexAnalyzers(s.index.Mapping()) {
+		languages[analyzer] = true
+	}
+
+	counts := map[string]uint64{}
+	dict, err := s.index.FieldDict(search.LanguageField)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get languages dictionary")
+	}
+	if err := readDict(dict, counts); err != nil {
+		return nil, errors.Wrap(err, "failed to read languages dictionary")
+	}
+	for lang := range counts {
+		languages[lang] = true
+	}
+
+	result := make([]string, 0, len(languages))
+	for lang := range languages {
+		result = append(result, lang)
+	}
+	sort.Strings(result)
+	return result, nil
+}
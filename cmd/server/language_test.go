@@ -0,0 +1,181 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+type testRuPost struct {
+	Title string `indexer:"text"`
+}
+
+func (p testRuPost) Type() string {
+	return "PostRu"
+}
+
+func (p testRuPost) Language() string {
+	return "ru"
+}
+
+type testNote struct {
+	Title string `indexer:"text"`
+	Lang  string
+}
+
+func (n testNote) Type() string {
+	return "Note"
+}
+
+func (n testNote) Language() string {
+	return n.Lang
+}
+
+func TestParseLanguages(t *testing.T) {
+	indexLanguages := func() ([]string, error) {
+		return []string{"en", "ru"}, nil
+	}
+
+	tt := []struct {
+		name    string
+		value   string
+		want    []language
+		wantErr bool
+	}{
+		{
+			name:  "single",
+			value: "en",
+			want:  []language{{name: "en", boost: 1}},
+		},
+		{
+			name:  "boosts",
+			value: "en^2,ru^0.5",
+			want:  []language{{name: "en", boost: 2}, {name: "ru", boost: 0.5}},
+		},
+		{
+			name:  "all",
+			value: "all",
+			want:  []language{{name: "en", boost: 1}, {name: "ru", boost: 1}},
+		},
+		{
+			name:  "custom analyzer",
+			value: "docs^2",
+			want:  []language{{name: "docs", boost: 2}},
+		},
+		{
+			name:  "built-in analyzer",
+			value: "standard,web",
+			want:  []language{{name: "standard", boost: 1}, {name: "web", boost: 1}},
+		},
+		{
+			name:    "unsupported",
+			value:   "en,xx",
+			wantErr: true,
+		},
+		{
+			name:    "invalid boost",
+			value:   "en^x",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			value:   ",",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseLanguages(tc.value, indexLanguages, registry.NewCache(), []string{"docs"})
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestHandleIndexMultilingual(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"en": testPage{Title: "Searching"},
+		"ru": testRuPost{Title: "Результаты поиска"},
+	})
+
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=search&lang=en"))
+	require.Equal(t, []string{"ru"}, searchIDs(t, srv, "/?q=результат&lang=ru"))
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=search&lang=all"))
+	require.Equal(t, []string{"ru"}, searchIDs(t, srv, "/?q=результат&lang=en,ru"))
+
+	got := searchIDs(t, srv, "/?q=searches+результаты&lang=all")
+	sort.Strings(got)
+	require.Equal(t, []string{"en", "ru"}, got)
+}
+
+func TestHandleIndexLocalizedFields(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"en": testNote{Title: "Searching", Lang: "en"},
+		"ru": testNote{Title: "Результаты поиска", Lang: "ru"},
+	})
+
+	require.Equal(t, []string{"ru"}, searchIDs(t, srv, "/?q=результат&fields=Title&lang=ru"))
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=searches&fields=Title&lang=en,ru"))
+
+	hits := searchHits(t, srv, "/?q=результат&fields=Title&lang=ru&highlight=html&locations=true")
+	require.Len(t, hits, 1)
+	require.Equal(t, map[string][]string{"Title": {"<mark>Результаты</mark> поиска"}}, hits[0].Fragments)
+	require.Len(t, hits[0].Locations, 1)
+	require.Equal(t, "Title", hits[0].Locations[0].Field)
+}
+
+func TestHandleIndexDocumentLanguages(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"en": testNote{Title: "Searching", Lang: "en"},
+		"de": testNote{Title: "Büchern", Lang: "de"},
+	})
+
+	languages, err := srv.indexLanguages()
+	require.NoError(t, err)
+	require.Equal(t, []string{"de", "en"}, languages)
+
+	require.Equal(t, []string{"de"}, searchIDs(t, srv, "/?q=buch&lang=all"))
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=searches&lang=all"))
+}
+
+func TestHandleIndexDetectLanguage(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"en": testNote{Title: "Searching", Lang: "en"},
+		"ru": testNote{Title: "Результаты поиска", Lang: "ru"},
+	})
+
+	require.Equal(t, []string{}, searchIDs(t, srv, "/?q=результаты"))
+
+	languages, err := srv.indexLanguages()
+	require.NoError(t, err)
+	srv.detector = search.NewLanguageDetector(languages...)
+
+	require.Equal(t, []string{"ru"}, searchIDs(t, srv, "/?q=результаты"))
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=searches"))
+}
+
+func TestHandleIndexCustomAnalyzer(t *testing.T) {
+	srv := newTestServerWithAnalyzers(t,
+		[]search.AnalyzerConfig{
+			{Name: "docs", Language: "en", Synonyms: []string{"k8s, kubernetes"}, ASCIIFolding: true},
+		},
+		map[string]interface{}{
+			"k8s":  testNote{Title: "Running K8s", Lang: "docs"},
+			"cafe": testNote{Title: "Café", Lang: "docs"},
+		},
+	)
+
+	require.Equal(t, []string{"k8s"}, searchIDs(t, srv, "/?q=kubernetes&lang=docs"))
+	require.Equal(t, []string{"k8s"}, searchIDs(t, srv, "/?q=run&lang=docs"))
+	require.Equal(t, []string{"cafe"}, searchIDs(t, srv, "/?q=cafe&lang=docs"))
+	require.Equal(t, []string{"cafe"}, searchIDs(t, srv, "/?q=café&lang=all"))
+}
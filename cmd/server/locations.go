@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2/search"
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/pkg/errors"
+)
+
+// locations returns locations of matched terms in each field of the hit,
+// with rune offsets computed from stored field values
+// (they are zero if the value isn't stored).
+// Hit must have locations of matches.
+func (s *server) locations(hit *search.DocumentMatch) ([]fragment, error) {
+	if len(hit.Locations) == 0 {
+		return nil, nil
+	}
+
+	doc, err := s.index.Document(hit.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load document %s", hit.ID)
+	}
+
+	// stored values of the fields with matches
+	values := map[string][]index.Field{}
+	if doc != nil {
+		doc.VisitFields(func(field index.Field) {
+			if _, ok := hit.Locations[field.Name()]; ok {
+				values[field.Name()] = append(values[field.Name()], field)
+			}
+		})
+	}
+
+	result := make([]fragment, 0, len(hit.Locations))
+	for field, termLocations := range hit.Locations {
+		f := fragment{Field: field, Locations: map[string][]location{}}
+		for term, locations := range termLocations {
+			for _, loc := range locations {
+				l := location{
+					Start:          loc.Start,
+					End:            loc.End,
+					ArrayPositions: loc.ArrayPositions,
+				}
+				if value := fieldValue(values[field], loc.ArrayPositions); value != nil && int(loc.End) <= len(value) {
+					l.RuneStart = uint64(utf8.RuneCount(value[:loc.Start]))
+					l.RuneEnd = l.RuneStart + uint64(utf8.RuneCount(value[loc.Start:loc.End]))
+				}
+				f.Locations[term] = append(f.Locations[term], l)
+			}
+		}
+		result = append(result, f)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Field < result[j].Field
+	})
+	return result, nil
+}
+
+// mergeLocalizedLocations moves locations of matches in localized fields
+// (see search.LocalizedField) to their text fields, which store the values.
+func (s *server) mergeLocalizedLocations(hits search.DocumentMatchCollection) {
+	m := s.index.Mapping()
+	for _, hit := range hits {
+		for field, termLocations := range hit.Locations {
+			base, ok := localizedFieldBase(m, field)
+			if !ok {
+				continue
+			}
+
+			if hit.Locations[base] == nil {
+				hit.Locations[base] = search.TermLocationMap{}
+			}
+			for term, locations := range termLocations {
+				hit.Locations[base][term] = append(hit.Locations[base][term], locations...)
+			}
+			delete(hit.Locations, field)
+		}
+	}
+}
+
+// fieldValue returns the value of the field at the array positions.
+func fieldValue(fields []index.Field, arrayPositions search.ArrayPositions) []byte {
+	for _, field := range fields {
+		if arrayPositions.Equals(field.ArrayPositions()) {
+			return field.Value()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleIndexLocations(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"post": testBlogPost{Title: "Über search", Body: "Searching"},
+	})
+
+	hits := searchHits(t, srv, "/?q=search")
+	require.Len(t, hits, 1)
+	require.Nil(t, hits[0].Locations)
+
+	hits = searchHits(t, srv, "/?q=search&locations=true")
+	require.Equal(t, []fragment{
+		{
+			Field: "Body",
+			Locations: map[string][]location{
+				"search": {{Start: 0, End: 9, RuneStart: 0, RuneEnd: 9}},
+			},
+		},
+		{
+			Field: "Title",
+			Locations: map[string][]location{
+				"search": {{Start: 6, End: 12, RuneStart: 5, RuneEnd: 11}},
+			},
+		},
+	}, hits[0].Locations)
+	require.Nil(t, hits[0].Fragments)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=search&locations=maybe", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -6,48 +6,23 @@ import (
 	"time"
 
 	bleve "github.com/blevesearch/bleve/v2"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ar"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/bg"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ca"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/cjk"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ckb"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/cs"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/da"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/de"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/el"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/en"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/es"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/eu"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/fa"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/fi"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/fr"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ga"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/gl"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hi"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hr"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hu"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hy"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/id"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/in"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/it"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/nl"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/no"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/pt"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ro"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ru"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/sv"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/tr"
 	"github.com/blevesearch/bleve/v2/registry"
 	"github.com/caarlos0/env/v6"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
 )
 
 type config struct {
-	Bind            string `env:"BIND" envDefault:"127.0.0.1:8081"`
-	IndexPath       string `env:"INDEX_PATH,required"`
-	DefaultLanguage string `env:"DEFAULT_LANGUAGE" envDefault:"en"`
+	Bind            string        `env:"BIND" envDefault:"127.0.0.1:8081"`
+	IndexPath       string        `env:"INDEX_PATH,required"`
+	DefaultLanguage string        `env:"DEFAULT_LANGUAGE" envDefault:"en"`
+	DetectLanguage  bool          `env:"DETECT_LANGUAGE" envDefault:"false"`
+	AnalyzersPath   string        `env:"ANALYZERS_PATH"`
+	SynonymsPath    string        `env:"SYNONYMS_PATH"`
+	SynonymsReload  time.Duration `env:"SYNONYMS_RELOAD_INTERVAL" envDefault:"30s"`
 }
 
 func main() {
@@ -80,6 +55,28 @@ func run() error {
 		defaultLanguage: cfg.DefaultLanguage,
 		cache:           registry.NewCache(),
 	}
+	if cfg.AnalyzersPath != "" {
+		if err := srv.loadAnalyzers(cfg.AnalyzersPath); err != nil {
+			return errors.Wrap(err, "failed to load analyzers")
+		}
+		log.Printf("Loaded custom analyzers: %v", srv.analyzers)
+	}
+	if cfg.SynonymsPath != "" {
+		srv.synonyms, err = loadSynonymsFile(cfg.SynonymsPath)
+		if err != nil {
+			return errors.Wrap(err, "failed to load synonyms")
+		}
+		go srv.synonyms.watch(cfg.SynonymsReload)
+		log.Printf("Loaded %d synonyms, reloading every %s", len(srv.synonyms.get()), cfg.SynonymsReload)
+	}
+	if cfg.DetectLanguage {
+		languages, err := srv.indexLanguages()
+		if err != nil {
+			return errors.Wrap(err, "failed to get index languages")
+		}
+		srv.detector = search.NewLanguageDetector(languages...)
+		log.Printf("Detecting query language among %d languages", srv.detector.Languages())
+	}
 	srv.routes()
 
 	log.Printf("Starting server on %s", cfg.Bind)
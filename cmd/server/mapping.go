@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+
+	"github.com/chuhlomin/search"
+)
+
+// fieldMappings returns explicit field mappings for the given path
+// across all document types registered in the index mapping.
+func fieldMappings(m mapping.IndexMapping, path string) []*mapping.FieldMapping {
+	impl, ok := m.(*mapping.IndexMappingImpl)
+	if !ok {
+		return nil
+	}
+
+	var result []*mapping.FieldMapping
+	for _, docMapping := range documentMappings(impl) {
+		result = append(result, fieldMappingsAt(docMapping, strings.Split(path, "."))...)
+	}
+	return result
+}
+
+// dynamicallyMapped reports whether the field without explicit mapping
+// is mapped dynamically by the default or any type mapping:
+// the closest document mapping of its path is dynamic.
+func dynamicallyMapped(m mapping.IndexMapping, path string) bool {
+	impl, ok := m.(*mapping.IndexMappingImpl)
+	if !ok {
+		return false
+	}
+
+	for _, docMapping := range documentMappings(impl) {
+		for _, name := range strings.Split(path, ".") {
+			sub, ok := docMapping.Properties[name]
+			if !ok {
+				break
+			}
+			docMapping = sub
+		}
+		if docMapping != nil && docMapping.Enabled && docMapping.Dynamic {
+			return true
+		}
+	}
+	return false
+}
+
+// documentMappings returns the default and all type mappings.
+func documentMappings(impl *mapping.IndexMappingImpl) []*mapping.DocumentMapping {
+	result := []*mapping.DocumentMapping{impl.DefaultMapping}
+	for _, docMapping := range impl.TypeMapping {
+		result = append(result, docMapping)
+	}
+	return result
+}
+
+func fieldMappingsAt(docMapping *mapping.DocumentMapping, path []string) []*mapping.FieldMapping {
+	if docMapping == nil || len(path) == 0 {
+		return nil
+	}
+
+	sub, ok := docMapping.Properties[path[0]]
+	if !ok {
+		return nil
+	}
+	if len(path) == 1 {
+		return sub.Fields
+	}
+	return fieldMappingsAt(sub, path[1:])
+}
+
+// indexAnalyzers returns sorted names of analyzers (languages)
+// used by text fields of the index, except suggestion fields.
+func indexAnalyzers(m mapping.IndexMapping) []string {
+	impl, ok := m.(*mapping.IndexMappingImpl)
+	if !ok {
+		return nil
+	}
+
+	analyzers := map[string]bool{}
+	collectAnalyzers(impl.DefaultMapping, analyzers)
+	for _, docMapping := range impl.TypeMapping {
+		collectAnalyzers(docMapping, analyzers)
+	}
+
+	var result []string
+	for analyzer := range analyzers {
+		result = append(result, analyzer)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func collectAnalyzers(docMapping *mapping.DocumentMapping, analyzers map[string]bool) {
+	if docMapping == nil {
+		return
+	}
+
+	for _, fieldMapping := range docMapping.Fields {
+		if fieldMapping.Type == "text" && fieldMapping.Index &&
+			fieldMapping.Analyzer != "" && fieldMapping.Analyzer != search.SuggestAnalyzer {
+			analyzers[fieldMapping.Analyzer] = true
+		}
+	}
+	for name, sub := range docMapping.Properties {
+		if isLocalizedMapping(name, sub) {
+			continue
+		}
+		collectAnalyzers(sub, analyzers)
+	}
+}
+
+// isLocalizedMapping reports whether the property maps a localized field
+// of a text field (see search.LocalizedField): it's named after its analyzer.
+// Indexer maps them for every language, documents may be in any of them.
+func isLocalizedMapping(name string, property *mapping.DocumentMapping) bool {
+	return len(property.Properties) == 0 && len(property.Fields) == 1 && property.Fields[0].Analyzer == name
+}
+
+// localizedFieldBase returns the text field of the localized field, e.g. "Title" for "Title.ru".
+func localizedFieldBase(m mapping.IndexMapping, field string) (string, bool) {
+	i := strings.LastIndex(field, ".")
+	if i == -1 {
+		return "", false
+	}
+	for _, fieldMapping := range fieldMappings(m, field) {
+		if fieldMapping.Analyzer == field[i+1:] {
+			return field[:i], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/pkg/errors"
+)
+
+// maxSlop limits the `slop` parameter, phrase matching gets slower
+// the more positions it has to try.
+const maxSlop = 50
+
+// parseSlop parses `slop` parameter: the number of position moves
+// allowed between words of quoted phrases.
+func parseSlop(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	slop, err := strconv.Atoi(value)
+	if err != nil || slop < 0 || slop > maxSlop {
+		return 0, errors.Errorf("slop must be an integer from 0 to %d, got %q", maxSlop, value)
+	}
+	return slop, nil
+}
+
+// splitPhrases extracts quoted phrases from the query string
+// and returns the rest of the query. Unclosed quote lasts till the end.
+func splitPhrases(queryString string) (string, []string) {
+	var text strings.Builder
+	var phrases []string
+
+	parts := strings.Split(queryString, `"`)
+	for i, part := range parts {
+		if i%2 == 0 {
+			text.WriteString(part)
+			text.WriteString(" ")
+			continue
+		}
+		if phrase := strings.TrimSpace(part); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	return strings.TrimSpace(text.String()), phrases
+}
+
+// analyzePhrase analyzes the phrase and returns terms at each position
+// starting from the first term. Positions without terms, like ones of removed
+// stop words, are kept empty, so the phrase matches only with a word in between.
+func analyzePhrase(analyzer *analysis.Analyzer, phrase string) [][]string {
+	var result [][]string
+	first := 0
+	for _, token := range analyzer.Analyze([]byte(phrase)) {
+		if result == nil {
+			first = token.Position
+		}
+		offset := token.Position - first
+		for len(result) <= offset {
+			result = append(result, nil)
+		}
+		result[offset] = append(result[offset], string(token.Term))
+	}
+	return result
+}
+
+// phraseQuery matches documents with the terms in the field at given positions,
+// allowing the total of slop position moves. Each position may have several
+// alternative terms (synonyms) or none (any word).
+type phraseQuery struct {
+	terms [][]string
+	field string
+	slop  int
+	boost float64
+}
+
+func newPhraseQuery(terms [][]string, field string, slop int) *phraseQuery {
+	return &phraseQuery{terms: terms, field: field, slop: slop, boost: 1}
+}
+
+func (q *phraseQuery) SetBoost(b float64) {
+	q.boost = b
+}
+
+func (q *phraseQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *phraseQuery) Searcher(i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
+	options.IncludeTermVectors = true
+
+	var positionSearchers []search.Searcher
+	closeAll := func() {
+		for _, s := range positionSearchers {
+			_ = s.Close()
+		}
+	}
+
+	for _, terms := range q.terms {
+		if len(terms) == 0 {
+			continue
+		}
+
+		var termSearchers []search.Searcher
+		for _, term := range terms {
+			ts, err := searcher.NewTermSearcher(i, term, q.field, q.boost, options)
+			if err != nil {
+				closeAll()
+				return nil, errors.Wrapf(err, "failed to create searcher for term %q", term)
+			}
+			termSearchers = append(termSearchers, ts)
+		}
+
+		if len(termSearchers) == 1 {
+			positionSearchers = append(positionSearchers, termSearchers[0])
+			continue
+		}
+		disjunction, err := searcher.NewDisjunctionSearcher(i, termSearchers, 1, options)
+		if err != nil {
+			closeAll()
+			return nil, errors.Wrap(err, "failed to create searcher for synonyms")
+		}
+		positionSearchers = append(positionSearchers, disjunction)
+	}
+
+	if len(positionSearchers) == 0 {
+		return searcher.NewMatchNoneSearcher(i)
+	}
+
+	conjunction, err := searcher.NewConjunctionSearcher(i, positionSearchers, options)
+	if err != nil {
+		closeAll()
+		return nil, errors.Wrap(err, "failed to create phrase searcher")
+	}
+	return &phraseSearcher{Searcher: conjunction, terms: q.terms, slop: q.slop}, nil
+}
+
+// phraseSearcher filters documents containing all phrase terms
+// down to ones where terms are close enough to each other.
+type phraseSearcher struct {
+	search.Searcher
+	terms [][]string
+	slop  int
+}
+
+func (s *phraseSearcher) Next(ctx *search.SearchContext) (*search.DocumentMatch, error) {
+	for {
+		match, err := s.Searcher.Next(ctx)
+		if err != nil || match == nil {
+			return match, err
+		}
+		if s.matches(match) {
+			return match, nil
+		}
+		ctx.DocumentMatchPool.Put(match)
+	}
+}
+
+func (s *phraseSearcher) Advance(ctx *search.SearchContext, ID index.IndexInternalID) (*search.DocumentMatch, error) {
+	match, err := s.Searcher.Advance(ctx, ID)
+	if err != nil || match == nil {
+		return match, err
+	}
+	if s.matches(match) {
+		return match, nil
+	}
+	ctx.DocumentMatchPool.Put(match)
+	return s.Next(ctx)
+}
+
+// matches reports whether phrase terms are found close enough
+// in any field value of the document.
+func (s *phraseSearcher) matches(match *search.DocumentMatch) bool {
+	locations := map[string]map[string][]search.Location{}
+	for _, ftl := range match.FieldTermLocations {
+		if locations[ftl.Field] == nil {
+			locations[ftl.Field] = map[string][]search.Location{}
+		}
+		locations[ftl.Field][ftl.Term] = append(locations[ftl.Field][ftl.Term], ftl.Location)
+	}
+
+	for _, termLocations := range locations {
+		if matchPhrase(s.terms, termLocations, nil, 0, s.slop) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPhrase reports whether the rest of phrase terms can be found
+// after the previous term location with the remaining slop. Slop is spent
+// on the distance between actual and expected positions of each term.
+func matchPhrase(terms [][]string, locations map[string][]search.Location, prev *search.Location, gap int, slop int) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	if len(terms[0]) == 0 {
+		return matchPhrase(terms[1:], locations, prev, gap+1, slop)
+	}
+
+	for _, term := range terms[0] {
+		for i := range locations[term] {
+			loc := &locations[term][i]
+
+			remaining := slop
+			if prev != nil {
+				if loc == prev || !loc.ArrayPositions.Equals(prev.ArrayPositions) {
+					continue
+				}
+				expected := int(prev.Pos) + gap + 1
+				remaining -= absInt(int(loc.Pos) - expected)
+				if remaining < 0 {
+					continue
+				}
+			}
+
+			if matchPhrase(terms[1:], locations, loc, 0, remaining) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
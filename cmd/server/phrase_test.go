@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlop(t *testing.T) {
+	slop, err := parseSlop("")
+	require.NoError(t, err)
+	require.Equal(t, 0, slop)
+
+	slop, err = parseSlop("3")
+	require.NoError(t, err)
+	require.Equal(t, 3, slop)
+
+	_, err = parseSlop("-1")
+	require.Error(t, err)
+
+	_, err = parseSlop("100")
+	require.Error(t, err)
+}
+
+func TestSplitPhrases(t *testing.T) {
+	tt := []struct {
+		value       string
+		wantText    string
+		wantPhrases []string
+	}{
+		{value: "golang errors", wantText: "golang errors"},
+		{value: `"error handling"`, wantPhrases: []string{"error handling"}},
+		{value: `go "error handling" tips`, wantText: "go   tips", wantPhrases: []string{"error handling"}},
+		{value: `"a b" "c d"`, wantPhrases: []string{"a b", "c d"}},
+		{value: `go "error handling`, wantText: "go", wantPhrases: []string{"error handling"}},
+		{value: `go ""`, wantText: "go"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			text, phrases := splitPhrases(tc.value)
+			require.Equal(t, tc.wantText, text)
+			require.Equal(t, tc.wantPhrases, phrases)
+		})
+	}
+}
+
+func TestAnalyzePhrase(t *testing.T) {
+	analyzer, err := registry.NewCache().AnalyzerNamed("en")
+	require.NoError(t, err)
+
+	require.Equal(t, [][]string{{"error"}, {"handl"}}, analyzePhrase(analyzer, "Error handling"))
+	require.Equal(t, [][]string{{"error"}, nil, nil, {"handl"}}, analyzePhrase(analyzer, "the error of the handling"))
+	require.Empty(t, analyzePhrase(analyzer, "the"))
+}
+
+func TestMatchPhrase(t *testing.T) {
+	// "quick brown fox jumps"
+	locations := map[string][]search.Location{
+		"quick": {{Pos: 1}},
+		"brown": {{Pos: 2}},
+		"fox":   {{Pos: 3}},
+		"jumps": {{Pos: 4}},
+	}
+
+	tt := []struct {
+		name  string
+		terms [][]string
+		slop  int
+		want  bool
+	}{
+		{name: "exact", terms: [][]string{{"quick"}, {"brown"}}, want: true},
+		{name: "gap", terms: [][]string{{"quick"}, nil, {"fox"}}, want: true},
+		{name: "gap mismatch", terms: [][]string{{"quick"}, nil, {"brown"}}, want: false},
+		{name: "distance", terms: [][]string{{"quick"}, {"fox"}}, want: false},
+		{name: "distance with slop", terms: [][]string{{"quick"}, {"fox"}}, slop: 1, want: true},
+		{name: "reversed", terms: [][]string{{"brown"}, {"quick"}}, slop: 1, want: false},
+		{name: "reversed with slop", terms: [][]string{{"brown"}, {"quick"}}, slop: 2, want: true},
+		{name: "synonyms", terms: [][]string{{"fast", "quick"}, {"brown"}}, want: true},
+		{name: "missing", terms: [][]string{{"quick"}, {"dog"}}, slop: 10, want: false},
+		{name: "same location twice", terms: [][]string{{"fox"}, {"fox"}}, slop: 10, want: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, matchPhrase(tc.terms, locations, nil, 0, tc.slop))
+		})
+	}
+}
+
+func TestHandleIndexPhrase(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"exact":    testPage{Title: "Error handling in Go"},
+		"reversed": testPage{Title: "Handling of every error"},
+		"distance": testPage{Title: "Error codes and proper handling"},
+	})
+
+	got := searchIDs(t, srv, "/?q=error+handling")
+	sort.Strings(got)
+	require.Equal(t, []string{"distance", "exact", "reversed"}, got)
+
+	require.Equal(t, []string{"exact"}, searchIDs(t, srv, `/?q="error+handling"`))
+	require.Equal(t, []string{"exact"}, searchIDs(t, srv, `/?q="error+handling"&slop=2`))
+
+	// "error codes and proper handling": "handling" is 3 positions further than expected
+	got = searchIDs(t, srv, `/?q="error+handling"&slop=3`)
+	sort.Strings(got)
+	require.Equal(t, []string{"distance", "exact"}, got)
+
+	// "handling of every error": reversed order costs more
+
+	got = searchIDs(t, srv, `/?q="error+handling"&slop=4`)
+	sort.Strings(got)
+	require.Equal(t, []string{"distance", "exact", "reversed"}, got)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/pkg/errors"
+)
+
+// fuzzinessAuto picks the edit distance from the term length.
+const fuzzinessAuto = -1
+
+// exactBoost makes documents matching query terms exactly
+// score above documents matching them only with typos.
+const exactBoost = 2.0
+
+// parseFuzziness parses `fuzziness` parameter: "auto", "0", "1" or "2".
+func parseFuzziness(value string) (int, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "auto":
+		return fuzzinessAuto, nil
+	}
+
+	fuzziness, err := strconv.Atoi(value)
+	if err != nil || fuzziness < 0 || fuzziness > 2 {
+		return 0, errors.Errorf("fuzziness must be one of auto, 0, 1, 2, got %q", value)
+	}
+	return fuzziness, nil
+}
+
+// parsePrefixLength parses `prefix_length` parameter: the number of leading
+// characters of a term which must match exactly in fuzzy search.
+func parsePrefixLength(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	prefix, err := strconv.Atoi(value)
+	if err != nil || prefix < 0 {
+		return 0, errors.Errorf("prefix_length must be a non-negative integer, got %q", value)
+	}
+	return prefix, nil
+}
+
+const (
+	operatorOr  = "or"
+	operatorAnd = "and"
+)
+
+// parseOperator parses `operator` parameter: "or" (default) if any of the query
+// words or phrases must match, "and" if all of them must match.
+func parseOperator(value string) (string, error) {
+	switch value {
+	case "":
+		return operatorOr, nil
+	case operatorOr, operatorAnd:
+		return value, nil
+	}
+	return "", errors.Errorf("operator must be one of and, or, got %q", value)
+}
+
+// minimumShouldMatch is the number or the percentage of query words and phrases
+// which must match. Negative value is the number (percentage) of ones which may be missing.
+type minimumShouldMatch struct {
+	value   int
+	percent bool
+}
+
+// parseMinimumShouldMatch parses `minimum_should_match` parameter,
+// e.g. "2", "-1", "75%" or "-25%".
+func parseMinimumShouldMatch(value string) (minimumShouldMatch, error) {
+	if value == "" {
+		return minimumShouldMatch{}, nil
+	}
+
+	percent := strings.HasSuffix(value, "%")
+	result, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil || percent && (result < -100 || result > 100) {
+		return minimumShouldMatch{}, errors.Errorf(
+			"minimum_should_match must be an integer or a percentage, got %q", value,
+		)
+	}
+	return minimumShouldMatch{value: result, percent: percent}, nil
+}
+
+// count returns the number of clauses which must match out of total.
+func (m minimumShouldMatch) count(total int) int {
+	var result int
+	switch {
+	case m.percent && m.value < 0:
+		// missing clauses are rounded down, so the number of required
+		// clauses never decreases when the query has more of them
+		result = total - total*-m.value/100
+	case m.percent:
+		result = total * m.value / 100
+	case m.value < 0:
+		result = total + m.value
+	default:
+		result = m.value
+	}
+
+	switch {
+	case result < 0:
+		return 0
+	case result > total:
+		return total
+	}
+	return result
+}
+
+// autoFuzziness returns the edit distance allowed for the term:
+// short terms must match exactly, longer ones may have one or two typos.
+func autoFuzziness(term string) int {
+	switch length := utf8.RuneCountInString(term); {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// analyze runs the query string through the analyzer once and groups
+// resulting terms by position: synonyms share the position of the word.
+func analyze(analyzer *analysis.Analyzer, queryString string) [][]string {
+	var result [][]string
+	position := 0
+	for _, token := range analyzer.Analyze([]byte(queryString)) {
+		if len(result) == 0 || token.Position != position {
+			result = append(result, nil)
+			position = token.Position
+		}
+		result[len(result)-1] = append(result[len(result)-1], string(token.Term))
+	}
+	return result
+}
+
+// queryOptions are parameters of text queries.
+type queryOptions struct {
+	fuzziness          int
+	prefix             int
+	slop               int
+	operator           string
+	minimumShouldMatch minimumShouldMatch
+}
+
+// textQuery matches the query string analyzed with the analyzer in the field.
+// Words match separately, allowing typos if fuzziness is set,
+// quoted phrases match as a whole, allowing slop.
+// Any of words and phrases must match, or as many as set by the options.
+// Terms are analyzed once and matched as is,
+// so they are not analyzed again with the field analyzer.
+func textQuery(analyzer *analysis.Analyzer, queryString, field string, options queryOptions) query.Query {
+	text, phrases := splitPhrases(queryString)
+
+	positions := analyze(analyzer, text)
+	clauses := make([]query.Query, 0, len(positions)+len(phrases))
+	for _, terms := range positions {
+		clauses = append(clauses, termsQuery(terms, field, options.fuzziness, options.prefix))
+	}
+	for _, phrase := range phrases {
+		if terms := analyzePhrase(analyzer, phrase); len(terms) > 0 {
+			clauses = append(clauses, newPhraseQuery(terms, field, options.slop))
+		}
+	}
+
+	if options.operator == operatorAnd && len(clauses) > 0 {
+		return bleve.NewConjunctionQuery(clauses...)
+	}
+	disjunction := bleve.NewDisjunctionQuery(clauses...)
+	disjunction.SetMin(float64(options.minimumShouldMatch.count(len(clauses))))
+	return disjunction
+}
+
+// termsQuery matches any of the terms at the same position,
+// exactly or with typos if fuzziness is set.
+// Exact matches score above matches with typos.
+func termsQuery(terms []string, field string, fuzziness, prefix int) query.Query {
+	disjunction := bleve.NewDisjunctionQuery()
+	for _, term := range terms {
+		exact := bleve.NewTermQuery(term)
+		exact.SetField(field)
+		disjunction.AddQuery(exact)
+
+		distance := fuzziness
+		if distance == fuzzinessAuto {
+			distance = autoFuzziness(term)
+		}
+		if distance == 0 {
+			continue
+		}
+
+		exact.SetBoost(exactBoost)
+		fuzzy := bleve.NewFuzzyQuery(term)
+		fuzzy.SetField(field)
+		fuzzy.SetFuzziness(distance)
+		fuzzy.SetPrefix(prefix)
+		disjunction.AddQuery(fuzzy)
+	}
+
+	if len(disjunction.Disjuncts) == 1 {
+		return disjunction.Disjuncts[0]
+	}
+	return disjunction
+}
+
+// boostQuery multiplies boosts of the query leaves by the boost:
+// bleve ignores boosts of disjunction and conjunction queries.
+func boostQuery(q query.Query, boost float64) {
+	switch q := q.(type) {
+	case *query.DisjunctionQuery:
+		for _, disjunct := range q.Disjuncts {
+			boostQuery(disjunct, boost)
+		}
+	case *query.ConjunctionQuery:
+		for _, conjunct := range q.Conjuncts {
+			boostQuery(conjunct, boost)
+		}
+	case query.BoostableQuery:
+		q.SetBoost(q.Boost() * boost)
+	}
+}
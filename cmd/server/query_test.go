@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+func TestParseFuzziness(t *testing.T) {
+	tt := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "auto", want: fuzzinessAuto},
+		{value: "0", want: 0},
+		{value: "1", want: 1},
+		{value: "2", want: 2},
+		{value: "3", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "many", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseFuzziness(tc.value)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAutoFuzziness(t *testing.T) {
+	require.Equal(t, 0, autoFuzziness("go"))
+	require.Equal(t, 1, autoFuzziness("kube"))
+	require.Equal(t, 1, autoFuzziness("поиск"))
+	require.Equal(t, 2, autoFuzziness("kubernetes"))
+}
+
+func TestAnalyze(t *testing.T) {
+	analyzer, err := registry.NewCache().AnalyzerNamed("en")
+	require.NoError(t, err)
+
+	require.Equal(t, [][]string{{"univers"}, {"run"}}, analyze(analyzer, "The universities running"))
+
+	synonyms := search.Synonyms{"k8s": {"k8s", "kubernetes"}}
+	require.Equal(t,
+		[][]string{{"k8", "kubernet"}, {"cluster"}},
+		analyze(withSynonyms(analyzer, synonyms), "K8s clusters"),
+	)
+}
+
+type testDutchPage struct {
+	Title string `indexer:"text"`
+}
+
+func (p testDutchPage) Type() string {
+	return "PageNl"
+}
+
+func (p testDutchPage) Language() string {
+	return "nl"
+}
+
+// Query terms must be analyzed only once: stemming a stem again may change it
+// ("universities" → "univers" → "univ") and analyzing it with another analyzer
+// may drop it (Dutch "allen" → "all", an English stop word).
+func TestHandleIndexStemming(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"en": testPage{Title: "University"},
+		"ru": testRuPost{Title: "Управление серверами"},
+		"nl": testDutchPage{Title: "Voor allen"},
+	})
+
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=universities"))
+	require.Equal(t, []string{"en"}, searchIDs(t, srv, "/?q=universities&fuzziness=auto"))
+	require.Equal(t, []string{"ru"}, searchIDs(t, srv, "/?q=управление&lang=ru"))
+	require.Equal(t, []string{"nl"}, searchIDs(t, srv, "/?q=allen&lang=nl"))
+
+	analyzer, err := srv.cache.AnalyzerNamed("en")
+	require.NoError(t, err)
+	result, err := srv.index.Search(bleve.NewSearchRequest(textQuery(analyzer, "universities", "Title", queryOptions{})))
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	require.Equal(t, "en", result.Hits[0].ID)
+}
+
+func TestParseOperator(t *testing.T) {
+	operator, err := parseOperator("")
+	require.NoError(t, err)
+	require.Equal(t, operatorOr, operator)
+
+	operator, err = parseOperator("and")
+	require.NoError(t, err)
+	require.Equal(t, operatorAnd, operator)
+
+	_, err = parseOperator("xor")
+	require.Error(t, err)
+}
+
+func TestMinimumShouldMatch(t *testing.T) {
+	tt := []struct {
+		value   string
+		total   int
+		want    int
+		wantErr bool
+	}{
+		{value: "", total: 3, want: 0},
+		{value: "2", total: 3, want: 2},
+		{value: "5", total: 3, want: 3},
+		{value: "-1", total: 3, want: 2},
+		{value: "-5", total: 3, want: 0},
+		{value: "75%", total: 4, want: 3},
+		{value: "75%", total: 3, want: 2},
+		{value: "-25%", total: 1, want: 1},
+		{value: "-25%", total: 2, want: 2},
+		{value: "-25%", total: 3, want: 3},
+		{value: "-25%", total: 4, want: 3},
+		{value: "-25%", total: 8, want: 6},
+		{value: "-50%", total: 3, want: 2},
+		{value: "-100%", total: 3, want: 0},
+		{value: "-3", total: 3, want: 0},
+		{value: "-4", total: 1, want: 0},
+		{value: "-4", total: 2, want: 0},
+		{value: "150%", wantErr: true},
+		{value: "some", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%s of %d", tc.value, tc.total), func(t *testing.T) {
+			msm, err := parseMinimumShouldMatch(tc.value)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, msm.count(tc.total))
+		})
+	}
+}
+
+func TestHandleIndexOperator(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"all":  testPage{Title: "Deploying Kubernetes clusters"},
+		"two":  testPage{Title: "Kubernetes clusters"},
+		"one":  testPage{Title: "Kubernetes"},
+		"none": testPage{Title: "Docker"},
+	})
+
+	got := searchIDs(t, srv, "/?q=deploying+kubernetes+clusters")
+	sort.Strings(got)
+	require.Equal(t, []string{"all", "one", "two"}, got)
+
+	require.Equal(t, []string{"all"}, searchIDs(t, srv, "/?q=deploying+kubernetes+clusters&operator=and"))
+	require.Equal(t, []string{"all"}, searchIDs(t, srv, `/?q="kubernetes+clusters"+deploying&operator=and`))
+
+	got = searchIDs(t, srv, "/?q=deploying+kubernetes+clusters&minimum_should_match=2")
+	sort.Strings(got)
+	require.Equal(t, []string{"all", "two"}, got)
+
+	got = searchIDs(t, srv, "/?q=deploying+kubernetes+clusters&minimum_should_match=-1")
+	sort.Strings(got)
+	require.Equal(t, []string{"all", "two"}, got)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=kubernetes&operator=and&minimum_should_match=1", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBoostQuery(t *testing.T) {
+	exact := bleve.NewTermQuery("kubernetes")
+	exact.SetBoost(exactBoost)
+	fuzzy := bleve.NewFuzzyQuery("kubernetes")
+	phrase := newPhraseQuery([][]string{{"kubernetes"}, {"operator"}}, "Title", 0)
+
+	q := bleve.NewDisjunctionQuery(
+		bleve.NewDisjunctionQuery(exact, fuzzy),
+		bleve.NewConjunctionQuery(phrase),
+	)
+	boostQuery(q, 3)
+
+	require.Equal(t, 3*exactBoost, exact.Boost())
+	require.Equal(t, 3.0, fuzzy.Boost())
+	require.Equal(t, 3.0, phrase.Boost())
+}
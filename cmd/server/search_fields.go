@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// searchField is a field to search in and its boost.
+type searchField struct {
+	name  string
+	boost float64
+
+	// analyzer is set for fields which are not analyzed with a language,
+	// like suggestion fields, query is analyzed with it instead.
+	analyzer *analysis.Analyzer
+
+	// localized is set for fields analyzed with a language, documents
+	// in other languages are searched in their localized fields.
+	localized bool
+}
+
+// parseSearchFields parses `fields` parameter: comma-separated list of text fields
+// with optional boosts, e.g. "Title^3,Summary^2,Body".
+// Without fields, the default search field (`_all`) is searched.
+func (s *server) parseSearchFields(value string) ([]searchField, error) {
+	m := s.index.Mapping()
+
+	items := parseList(value)
+	if len(items) == 0 {
+		return []searchField{{name: m.DefaultSearchField(), boost: 1}}, nil
+	}
+
+	result := make([]searchField, 0, len(items))
+	for _, item := range items {
+		name, boost, err := parseBoost(item)
+		if err != nil {
+			return nil, err
+		}
+
+		analyzerName, ok := textFieldAnalyzer(m, name)
+		if !ok {
+			return nil, errors.Errorf("field %q is not a text field", name)
+		}
+
+		field := searchField{name: name, boost: boost, localized: true}
+		if !search.IsLanguageSupported(analyzerName) && !contains(s.analyzers, analyzerName) {
+			field.analyzer = m.AnalyzerNamed(analyzerName)
+			if field.analyzer == nil {
+				return nil, errors.Errorf("unknown analyzer %q of field %q", analyzerName, name)
+			}
+			field.localized = false
+		}
+		result = append(result, field)
+	}
+	return result, nil
+}
+
+// parseBoost parses an item with optional boost, e.g. "Title^3".
+func parseBoost(item string) (string, float64, error) {
+	name, boostString, found := strings.Cut(item, "^")
+	if !found {
+		return name, 1, nil
+	}
+
+	boost, err := strconv.ParseFloat(boostString, 64)
+	if err != nil || boost <= 0 {
+		return "", 0, errors.Errorf("invalid boost for %q: %q", name, boostString)
+	}
+	return name, boost, nil
+}
+
+// textFieldAnalyzer returns the name of the analyzer of the indexed text field.
+func textFieldAnalyzer(m mapping.IndexMapping, path string) (string, bool) {
+	name := path[strings.LastIndex(path, ".")+1:]
+	for _, fieldMapping := range fieldMappings(m, path) {
+		if fieldMapping.Type != "text" || !fieldMapping.Index {
+			continue
+		}
+		if fieldMapping.Name != "" && fieldMapping.Name != name {
+			continue // e.g. suggestion field
+		}
+
+		if fieldMapping.Analyzer == "" {
+			return m.AnalyzerNameForPath(path), true
+		}
+		return fieldMapping.Analyzer, true
+	}
+	return "", false
+}
+
+// fieldsQuery searches the query string in each of the fields analyzed with each of the languages,
+// or the field own analyzer, and combines results, so documents matching
+// in fields with higher boost score higher.
+func fieldsQuery(
+	fields []searchField,
+	languages []language,
+	analyzers []*analysis.Analyzer,
+	queryString string,
+	options queryOptions,
+) query.Query {
+	fieldQueries := make([]query.Query, 0, len(fields))
+	for _, field := range fields {
+		var fieldQuery query.Query
+		if field.analyzer != nil {
+			fieldQuery = textQuery(field.analyzer, queryString, field.name, options)
+		} else {
+			languageQueries := make([]query.Query, 0, len(languages))
+			for i, language := range languages {
+				languageQuery := textQuery(analyzers[i], queryString, field.name, options)
+				if field.localized {
+					languageQuery = bleve.NewDisjunctionQuery(
+						languageQuery,
+						textQuery(analyzers[i], queryString, search.LocalizedField(field.name, language.name), options),
+					)
+				}
+				boostQuery(languageQuery, language.boost)
+				languageQueries = append(languageQueries, languageQuery)
+			}
+
+			fieldQuery = languageQueries[0]
+			if len(languageQueries) > 1 {
+				fieldQuery = bleve.NewDisjunctionQuery(languageQueries...)
+			}
+		}
+
+		boostQuery(fieldQuery, field.boost)
+		fieldQueries = append(fieldQueries, fieldQuery)
+	}
+
+	if len(fieldQueries) == 1 {
+		return fieldQueries[0]
+	}
+	return bleve.NewDisjunctionQuery(fieldQueries...)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testBlogPost struct {
+	Title   string `indexer:"text"`
+	Summary string `indexer:"text"`
+	Body    string `indexer:"text"`
+	Slug    string `indexer:"no_index"`
+}
+
+func (p testBlogPost) Type() string {
+	return "BlogPost"
+}
+
+func TestParseBoost(t *testing.T) {
+	name, boost, err := parseBoost("Title")
+	require.NoError(t, err)
+	require.Equal(t, "Title", name)
+	require.Equal(t, 1.0, boost)
+
+	name, boost, err = parseBoost("Title^2.5")
+	require.NoError(t, err)
+	require.Equal(t, "Title", name)
+	require.Equal(t, 2.5, boost)
+
+	_, _, err = parseBoost("Title^0")
+	require.Error(t, err)
+
+	_, _, err = parseBoost("Title^x")
+	require.Error(t, err)
+}
+
+func TestParseSearchFields(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"post": testBlogPost{Title: "Kubernetes"},
+	})
+
+	fields, err := srv.parseSearchFields("")
+	require.NoError(t, err)
+	require.Equal(t, []searchField{{name: "_all", boost: 1}}, fields)
+
+	fields, err = srv.parseSearchFields("Title^3,Body")
+	require.NoError(t, err)
+	require.Equal(t, []searchField{{name: "Title", boost: 3, localized: true}, {name: "Body", boost: 1, localized: true}}, fields)
+
+	_, err = srv.parseSearchFields("Slug")
+	require.EqualError(t, err, `field "Slug" is not a text field`)
+
+	_, err = srv.parseSearchFields("Missing")
+	require.EqualError(t, err, `field "Missing" is not a text field`)
+}
+
+func TestHandleIndexFields(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"title":   testBlogPost{Title: "Kubernetes operators", Body: "How to write them"},
+		"summary": testBlogPost{Title: "Operators", Summary: "Writing them for Kubernetes"},
+		"body":    testBlogPost{Title: "Operators", Body: "Kubernetes operators are clients of the Kubernetes API"},
+	})
+
+	require.Equal(t, []string{"title", "summary", "body"}, searchIDs(t, srv, "/?q=kubernetes&fields=Title^9,Summary^3,Body"))
+	require.Equal(t, []string{"body", "summary", "title"}, searchIDs(t, srv, "/?q=kubernetes&fields=Title,Summary^3,Body^9"))
+	require.Equal(t, []string{"summary"}, searchIDs(t, srv, "/?q=kubernetes&fields=Summary"))
+	require.Equal(t, []string{"title", "body"}, searchIDs(t, srv, `/?q="kubernetes+operators"&fields=Title^2,Body`))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?q=kubernetes&fields=Slug", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
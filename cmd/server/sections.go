@@ -0,0 +1,142 @@
+package main
+
+import (
+	bleve "github.com/blevesearch/bleve/v2"
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// resultSize is the number of documents in the response.
+const resultSize = 10
+
+// searchSize is the number of hits to search for if the index has sections
+// or hits are collapsed: sections of a document are separate hits,
+// several of them may be grouped into one result.
+const searchSize = resultSize * 5
+
+// hitGroup is a document with hits of itself and of its sections
+// (see search.ParentField).
+type hitGroup struct {
+	id    string
+	score float64 // score of the best hit
+	hit   *bleveSearch.DocumentMatch
+
+	// anchor and ID of the best matching section, if any
+	anchor  string
+	section string
+
+	fields    map[string]interface{}
+	hasFields bool
+
+	// inner are groups collapsed into this one, see collapseGroups
+	inner []*hitGroup
+}
+
+// withoutSections excludes sections from results of the query.
+// Sections have no values of document fields to sort by and would push
+// documents out of searched hits, so sorted results are documents only:
+// documents have the whole text of their sections.
+func withoutSections(q query.Query) query.Query {
+	sections := bleve.NewBoolFieldQuery(true)
+	sections.SetField(search.SectionField)
+	result := bleve.NewBooleanQuery()
+	result.AddMust(q)
+	result.AddMustNot(sections)
+	return result
+}
+
+// hasSections reports whether the index has sections of documents.
+func (s *server) hasSections() (bool, error) {
+	fields, err := s.index.Fields()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get index fields")
+	}
+	return contains(fields, search.SectionField), nil
+}
+
+// sectionFields adds fields sections are grouped by to requested fields.
+func sectionFields(fields []string) []string {
+	return append(fields[:len(fields):len(fields)], search.ParentField, search.AnchorField)
+}
+
+// groupSections groups hits of sections with hits of their documents,
+// in the order of the best hit of each document.
+func groupSections(hits bleveSearch.DocumentMatchCollection) []*hitGroup {
+	groups := []*hitGroup{}
+	byID := map[string]*hitGroup{}
+	for _, hit := range hits {
+		parent, _ := hit.Fields[search.ParentField].(string)
+		id := hit.ID
+		if parent != "" {
+			id = parent
+		}
+
+		group, ok := byID[id]
+		if !ok {
+			group = &hitGroup{id: id, score: hit.Score, hit: hit}
+			byID[id] = group
+			groups = append(groups, group)
+		}
+		if hit.Score > group.score {
+			group.score = hit.Score
+		}
+
+		if parent == "" {
+			group.fields = documentFields(hit.Fields)
+			group.hasFields = true
+		} else if group.section == "" {
+			group.section = hit.ID
+			group.anchor, _ = hit.Fields[search.AnchorField].(string)
+		}
+	}
+	return groups
+}
+
+// loadDocuments loads requested fields of documents
+// which were found only by their sections.
+func (s *server) loadDocuments(groups []*hitGroup, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	byID := map[string]*hitGroup{}
+	var ids []string
+	for _, group := range groups {
+		if !group.hasFields {
+			byID[group.id] = group
+			ids = append(ids, group.id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	request := bleve.NewSearchRequestOptions(bleve.NewDocIDQuery(ids), len(ids), 0, false)
+	request.Fields = fields
+	result, err := s.index.Search(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to load documents")
+	}
+	for _, hit := range result.Hits {
+		byID[hit.ID].fields = documentFields(hit.Fields)
+		byID[hit.ID].hasFields = true
+	}
+	return nil
+}
+
+// documentFields returns fields of the hit without fields of sections.
+func documentFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if name != search.ParentField && name != search.AnchorField {
+			result[name] = value
+		}
+	}
+	return result
+}
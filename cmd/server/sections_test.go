@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testGuide struct {
+	Title string `indexer:"text"`
+	Body  string `indexer:"text,format=markdown,sections"`
+}
+
+func (g testGuide) Type() string {
+	return "Guide"
+}
+
+func TestHandleIndexSections(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"/install": testGuide{
+			Title: "Installation",
+			Body:  "# Requirements\n\nKubernetes cluster.\n\n# Helm\n\nInstall the helm chart.\n\n# Upgrade\n\nUpgrade the helm release.\n",
+		},
+		"/helm": testGuide{Title: "Helm charts", Body: "No headings."},
+		"/other": testGuide{
+			Title: "Other",
+			Body:  "# Docker\n\nBuild images.\n",
+		},
+	})
+
+	search := func(target, body string) []response {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var hits []response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hits))
+		return hits
+	}
+
+	// sections are grouped under their document
+	hits := search("/?q=helm&fields=Body", `{"Title": true}`)
+	require.Len(t, hits, 1)
+	install := hits[0]
+	require.Equal(t, "/install", install.ID)
+	require.Contains(t, []string{"helm", "upgrade"}, install.Anchor)
+	require.Equal(t, "/install#"+install.Anchor, install.Section)
+	require.Equal(t, map[string]interface{}{"Title": "Installation"}, install.Document)
+
+	// type filter matches sections, they are grouped as well
+	hits = search("/?q=helm&fields=Body&type=Guide", "")
+	require.Len(t, hits, 1)
+	require.Equal(t, "/install", hits[0].ID)
+
+	// document is found by its section only
+	hits = search("/?q=images&fields=Body", `{"Title": true}`)
+	require.Len(t, hits, 1)
+	require.Equal(t, "/other", hits[0].ID)
+	require.Equal(t, "docker", hits[0].Anchor)
+	require.Equal(t, "/other#docker", hits[0].Section)
+	require.Equal(t, map[string]interface{}{"Title": "Other"}, hits[0].Document)
+
+	// highlights are of the best section
+	hits = search("/?q=images&fields=Body&highlight=plain", "")
+	require.Len(t, hits, 1)
+	require.Equal(t, []string{"Docker\nBuild images."}, hits[0].Fragments["Body"])
+
+	// documents without sections
+	hits = search("/?q=charts&fields=Title", "")
+	require.Len(t, hits, 1)
+	require.Equal(t, "/helm", hits[0].ID)
+	require.Empty(t, hits[0].Anchor)
+	require.Empty(t, hits[0].Section)
+}
+
+func TestHandleIndexSectionsSort(t *testing.T) {
+	var body strings.Builder
+	for i := 0; i < searchSize; i++ {
+		body.WriteString("# Helm\n\nInstall the helm chart.\n\n")
+	}
+	docs := map[string]interface{}{
+		"/a": testGuide{Title: "Helm sections", Body: body.String()},
+	}
+	for _, id := range []string{"/b", "/c", "/d", "/e", "/f", "/g", "/h", "/i", "/j", "/k", "/l"} {
+		docs[id] = testGuide{Title: "Helm " + id, Body: "No headings."}
+	}
+	srv := newTestServer(t, docs)
+
+	// sections have no values to sort by, documents are sorted without them
+	hits := searchHits(t, srv, "/?q=helm&sort=_id")
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+		require.Empty(t, hit.Anchor)
+	}
+	require.Equal(t, []string{"/a", "/b", "/c", "/d", "/e", "/f", "/g", "/h", "/i", "/j"}, ids)
+}
+
+func TestHasSections(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"/helm": testGuide{Title: "Helm charts", Body: "No headings."},
+	})
+	hasSections, err := srv.hasSections()
+	require.NoError(t, err)
+	require.False(t, hasSections)
+
+	srv = newTestServer(t, map[string]interface{}{
+		"/install": testGuide{Title: "Installation", Body: "# Helm\n\nInstall the helm chart.\n"},
+	})
+	hasSections, err = srv.hasSections()
+	require.NoError(t, err)
+	require.True(t, hasSections)
+}
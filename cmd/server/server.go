@@ -7,6 +7,8 @@ import (
 	bleve "github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/registry"
 	"github.com/go-chi/chi/v5"
+
+	"github.com/chuhlomin/search"
 )
 
 type server struct {
@@ -14,6 +16,9 @@ type server struct {
 	index           bleve.Index
 	defaultLanguage string
 	cache           *registry.Cache
+	detector        *search.LanguageDetector
+	analyzers       []string // names of custom analyzers
+	synonyms        *synonymsFile
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -22,6 +27,8 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (s *server) routes() {
 	s.router.HandleFunc("/", s.handleIndex())
+	s.router.HandleFunc("/suggest", s.handleSuggest())
+	s.router.HandleFunc("/languages", s.handleLanguages())
 	s.router.HandleFunc("/help", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain")
 		fmt.Fprintf(w, `TBA`)
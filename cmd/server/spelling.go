@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/pkg/errors"
+)
+
+// didYouMean proposes spelling corrections for the query, which returned no results.
+// Each query word missing from dictionaries of suggestion fields is replaced
+// with the closest and most frequent term within the edit distance allowed by autoFuzziness.
+// Terms are looked up with fuzzy dictionary search, not by reading whole dictionaries.
+// The handler rejects did_you_mean without suggestion fields: other fields hold stemmed terms.
+func (s *server) didYouMean(queryString string) ([]string, error) {
+	words := strings.FieldsFunc(strings.ToLower(queryString), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	fields, err := s.suggestFields()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	advanced, err := s.index.Advanced()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get index")
+	}
+	reader, err := advanced.Reader()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get index reader")
+	}
+	defer reader.Close()
+
+	fuzzyReader, ok := reader.(index.IndexReaderFuzzy)
+	if !ok {
+		return nil, errors.New("index doesn't support fuzzy dictionaries")
+	}
+
+	corrected := false
+	for i, word := range words {
+		maxDistance := autoFuzziness(word)
+		if maxDistance == 0 {
+			continue
+		}
+
+		counts := map[string]uint64{}
+		for _, field := range fields {
+			dict, err := fuzzyReader.FieldDictFuzzy(field, word, maxDistance, "")
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get %s dictionary", field)
+			}
+			if err := readDict(dict, counts); err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s dictionary", field)
+			}
+		}
+
+		if correction := correctWord(word, counts); correction != word {
+			words[i] = correction
+			corrected = true
+		}
+	}
+	if !corrected {
+		return nil, nil
+	}
+
+	return []string{strings.Join(words, " ")}, nil
+}
+
+// correctWord returns the closest dictionary term to the word,
+// preferring more frequent terms among equally close ones.
+// The word is returned as is if it's in the dictionary or nothing is close enough.
+func correctWord(word string, counts map[string]uint64) string {
+	if _, ok := counts[word]; ok {
+		return word
+	}
+
+	maxDistance := autoFuzziness(word)
+	best, bestDistance := word, maxDistance+1
+	for term, count := range counts {
+		distance := levenshtein(word, term)
+		if distance > maxDistance {
+			continue
+		}
+
+		if distance < bestDistance ||
+			distance == bestDistance && (count > counts[best] || count == counts[best] && term < best) {
+			best, bestDistance = term, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevenshtein(t *testing.T) {
+	require.Equal(t, 0, levenshtein("search", "search"))
+	require.Equal(t, 1, levenshtein("serch", "search"))
+	require.Equal(t, 2, levenshtein("kubernetis", "kubernetes!"))
+	require.Equal(t, 3, levenshtein("", "abc"))
+	require.Equal(t, 1, levenshtein("поиск", "поиски"))
+}
+
+func TestCorrectWord(t *testing.T) {
+	counts := map[string]uint64{
+		"search":  1,
+		"searchs": 5,
+		"go":      3,
+	}
+
+	require.Equal(t, "search", correctWord("search", counts), "known word")
+	require.Equal(t, "search", correctWord("serch", counts), "closest word")
+	require.Equal(t, "searchs", correctWord("searchx", counts), "more frequent word")
+	require.Equal(t, "gp", correctWord("gp", counts), "short word")
+	require.Equal(t, "unrelated", correctWord("unrelated", counts), "no match")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/pkg/errors"
+
+	"github.com/chuhlomin/search"
+)
+
+// synonymsFile holds query-time synonyms loaded from the file in Solr format
+// and reloads them when the file changes.
+type synonymsFile struct {
+	path string
+
+	mu       sync.RWMutex
+	synonyms search.Synonyms
+	modTime  time.Time
+}
+
+func loadSynonymsFile(path string) (*synonymsFile, error) {
+	f := &synonymsFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// reload reads the file again if it was modified since the last load.
+// Previously loaded synonyms are kept if the file is invalid.
+func (f *synonymsFile) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", f.path)
+	}
+
+	f.mu.RLock()
+	modified := !info.ModTime().Equal(f.modTime)
+	f.mu.RUnlock()
+	if !modified {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", f.path)
+	}
+	defer file.Close()
+
+	synonyms, err := search.ParseSynonyms(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", f.path)
+	}
+
+	f.mu.Lock()
+	f.synonyms = synonyms
+	f.modTime = info.ModTime()
+	f.mu.Unlock()
+	return nil
+}
+
+// watch reloads the file every interval.
+func (f *synonymsFile) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := f.reload(); err != nil {
+			log.Printf("Error reloading synonyms: %v", err)
+		}
+	}
+}
+
+func (f *synonymsFile) get() search.Synonyms {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.synonyms
+}
+
+// withSynonyms returns a copy of the analyzer, which expands query words
+// with their synonyms before other token filters, so synonyms are
+// lowercased and stemmed the same way as the query words.
+func withSynonyms(analyzer *analysis.Analyzer, synonyms search.Synonyms) *analysis.Analyzer {
+	if len(synonyms) == 0 {
+		return analyzer
+	}
+
+	tokenFilters := make([]analysis.TokenFilter, 0, len(analyzer.TokenFilters)+1)
+	tokenFilters = append(tokenFilters, synonyms)
+	tokenFilters = append(tokenFilters, analyzer.TokenFilters...)
+
+	return &analysis.Analyzer{
+		CharFilters:  analyzer.CharFilters,
+		Tokenizer:    analyzer.Tokenizer,
+		TokenFilters: tokenFilters,
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/chuhlomin/search"
+)
+
+func TestSynonymsFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	require.NoError(t, os.WriteFile(path, []byte("k8s, kubernetes\n"), 0644))
+
+	f, err := loadSynonymsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, search.Synonyms{
+		"k8s":        {"k8s", "kubernetes"},
+		"kubernetes": {"k8s", "kubernetes"},
+	}, f.get())
+
+	// invalid file keeps previous synonyms
+	require.NoError(t, os.WriteFile(path, []byte("new york, nyc\n"), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	require.Error(t, f.reload())
+	require.Contains(t, f.get(), "k8s")
+
+	require.NoError(t, os.WriteFile(path, []byte("tv => television\n"), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	require.NoError(t, f.reload())
+	require.Equal(t, search.Synonyms{"tv": {"television"}}, f.get())
+
+	_, err = loadSynonymsFile(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
+
+func TestHandleIndexSynonyms(t *testing.T) {
+	srv := newTestServer(t, map[string]interface{}{
+		"k8s":   testPage{Title: "Deploying Kubernetes clusters"},
+		"other": testPage{Title: "Docker images"},
+	})
+	require.Empty(t, searchIDs(t, srv, "/?q=k8s"))
+
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	require.NoError(t, os.WriteFile(path, []byte("K8s, kubernetes\n"), 0644))
+
+	var err error
+	srv.synonyms, err = loadSynonymsFile(path)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"k8s"}, searchIDs(t, srv, "/?q=K8s"))
+	require.Equal(t, []string{"k8s"}, searchIDs(t, srv, "/?q=k8s+cluster&lang=en,ru"))
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/pkg/errors"
+)
+
+// Fields are values of a document by field name.
+// Values of nested objects are Fields or maps too.
+type Fields map[string]interface{}
+
+// Document is a document which fields are known only at runtime,
+// like one read from a JSON file. Its type must be registered with RegisterFields.
+type Document struct {
+	// Fields are embedded with empty name,
+	// so they are mapped as fields of the document itself.
+	Fields `json:","`
+
+	DocType string `json:"-"`
+	Lang    string `json:"-"`
+}
+
+func (d Document) Type() string {
+	return d.DocType
+}
+
+func (d Document) Language() string {
+	return d.Lang
+}
+
+// fieldKinds are supported kinds of `indexer` tags.
+var fieldKinds = map[string]bool{
+	"text":     true,
+	"keyword":  true,
+	"number":   true,
+	"bool":     true,
+	"date":     true,
+	"no_index": true,
+	"no_store": true,
+}
+
+// RegisterFields registers a type of documents with runtime fields (see Document).
+// Fields map field names to their `indexer` tags, e.g. "text,suggest" or "date";
+// dots in names separate fields of nested objects, e.g. "author.name".
+func (i *Indexer) RegisterFields(docType string, fields map[string]string, lang string) error {
+	if _, ok := i.documemtMappings[docType]; ok {
+		return nil
+	}
+
+	if lang != "" && !i.indexMapping.hasAnalyzer(lang) {
+		return errors.Errorf("unsupported language %q of type %s", lang, docType)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	docMapping := mapping.NewDocumentMapping()
+	markup := newFieldMarkup()
+	for _, name := range names {
+		tag := parseFieldTag(fields[name])
+		if !fieldKinds[tag.kind] {
+			return errors.Errorf("unsupported kind %q of field %s of type %s", tag.kind, name, docType)
+		}
+		if err := markup.add(name, tag); err != nil {
+			return errors.Wrapf(err, "failed to register type %s", docType)
+		}
+
+		parent, fieldName := subDocumentMapping(docMapping, name)
+		addFieldMapping(parent, fieldName, tag, lang)
+	}
+
+	i.indexMapping.AddDocumentMapping(docType, docMapping)
+	i.indexMapping.languages[docType] = lang
+	i.indexMapping.markup[docType] = markup
+	i.documemtMappings[docType] = docMapping
+
+	return nil
+}
+
+// subDocumentMapping returns the mapping of the object the field at path belongs to,
+// adding mappings of nested objects if needed, and the name of the field in it.
+func subDocumentMapping(docMapping *mapping.DocumentMapping, path string) (*mapping.DocumentMapping, string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := docMapping.Properties[part]
+		if !ok {
+			sub = mapping.NewDocumentMapping()
+			docMapping.AddSubDocumentMapping(part, sub)
+		}
+		docMapping = sub
+	}
+	return docMapping, parts[len(parts)-1]
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"os"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexerDocument(t *testing.T) {
+	path := "ignore/document"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterFields("article", map[string]string{
+		"title":       "text,suggest",
+		"body":        "text,format=markdown",
+		"published":   "date",
+		"author.name": "text",
+		"secret":      "no_store",
+	}, "en")
+	require.NoError(t, err, "failed to register fields")
+
+	err = indexer.Index("one", Document{
+		DocType: "article",
+		Fields: Fields{
+			"title":     "Deploying services",
+			"body":      "See the [guide](https://example.com/kubernetes).",
+			"published": "2022-05-01",
+			"author":    map[string]interface{}{"name": "Alice"},
+			"secret":    "password",
+			"tags":      []interface{}{"ops"},
+		},
+	})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("two", Document{
+		DocType: "article",
+		Lang:    "ru",
+		Fields:  Fields{"title": "Развертывание сервисов"},
+	})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	require.Equal(t, []string{"one"}, searchText(index, "title:deploy", t))
+	require.Equal(t, []string{"one"}, searchText(index, "title_suggest:deploying", t))
+	require.Equal(t, []string{"one"}, searchText(index, "body:guide", t))
+	require.Empty(t, searchText(index, "body:kubernetes", t))
+	require.Equal(t, []string{"one"}, searchText(index, "author.name:alice", t))
+	require.Equal(t, []string{"one"}, searchText(index, `published:>="2022-01-01"`, t))
+	require.Equal(t, []string{"one"}, searchText(index, "tags:ops", t))
+	require.Empty(t, searchText(index, "password", t))
+	require.ElementsMatch(t, []string{"one", "two"}, searchText(index, "_type:article", t))
+	// documents in other languages are searched in localized fields
+	require.Equal(t, []string{"two"}, searchText(index, "title.ru:сервисов", t))
+	require.Empty(t, searchText(index, "title:сервис", t))
+}
+
+func TestIndexerDocumentDetectLanguage(t *testing.T) {
+	path := "ignore/document_detect"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+	indexer.SetLanguageDetector(NewLanguageDetector("en", "ru"))
+
+	err = indexer.RegisterFields("article", map[string]string{"title": "text"}, "en")
+	require.NoError(t, err, "failed to register fields")
+
+	err = indexer.Index("detected", Document{
+		DocType: "article",
+		Fields:  Fields{"title": "Развертывание сервисов"},
+	})
+	require.NoError(t, err, "failed to index")
+
+	// own language of the document isn't detected
+	err = indexer.Index("own", Document{
+		DocType: "article",
+		Lang:    "en",
+		Fields:  Fields{"title": "Развертывание серверов"},
+	})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	langQuery := bleve.NewTermQuery("ru")
+	langQuery.SetField(LanguageField)
+	require.Equal(t, []string{"detected"}, search(index, langQuery, t))
+	require.Equal(t, []string{"detected"}, searchText(index, "title.ru:сервис", t))
+}
+
+func TestIndexerRegisterFieldsErrors(t *testing.T) {
+	indexer, err := NewIndexer("ignore/register_fields", "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterFields("article", map[string]string{"title": "txt"}, "en")
+	require.EqualError(t, err, `unsupported kind "txt" of field title of type article`)
+
+	err = indexer.RegisterFields("article", map[string]string{"title": "date,format=html"}, "en")
+	require.EqualError(t, err, "failed to register type article: format of field title can only be set for text fields")
+
+	err = indexer.RegisterFields("article", map[string]string{"title": "text"}, "xx")
+	require.EqualError(t, err, `unsupported language "xx" of type article`)
+}
@@ -5,62 +5,74 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 
 	bleve "github.com/blevesearch/bleve/v2"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ar"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/bg"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ca"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/cjk"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ckb"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/cs"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/da"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/de"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/el"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/en"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/es"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/eu"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/fa"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/fi"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/fr"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ga"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/gl"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hi"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hr"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hu"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/hy"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/id"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/in"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/it"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/nl"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/no"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/pt"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ro"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/ru"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/sv"
-	_ "github.com/blevesearch/bleve/v2/analysis/lang/tr"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/document"
 	"github.com/blevesearch/bleve/v2/mapping"
+	index "github.com/blevesearch/bleve_index_api"
 	"github.com/pkg/errors"
 )
 
 type Indexer struct {
-	indexMapping *mapping.IndexMappingImpl
+	indexMapping *indexMapping
 	indexPath    string
 	buildDir     string
+	buildPath    string // temporary directory of the builder in buildDir
 	builder      bleve.Builder
 
 	documemtMappings map[string]*mapping.DocumentMapping
 	textAnalizers    map[string]*mapping.FieldMapping
 }
 
+// SuggestAnalyzer is the name of the analyzer used for suggestion fields.
+// It keeps whole lowercased words, so the term dictionary of a suggestion field
+// can be used to complete partially typed words.
+const SuggestAnalyzer = "suggest"
+
+// SuggestFieldSuffix is appended to the name of text fields tagged with
+// `suggest` option to get the name of their suggestion field.
+const SuggestFieldSuffix = "_suggest"
+
+// LanguageField is the name of the field which stores
+// the language each document was indexed with.
+const LanguageField = "_lang"
+
+// LocalizedField returns the name of the field which holds values of the text field
+// of documents in the language other than the language of their type, e.g. "Title.ru".
+// It is analyzed with the language analyzer, the text field keeps stored values only.
+func LocalizedField(field, lang string) string {
+	return field + "." + lang
+}
+
 type Language interface {
 	Language() string
 }
 
 func NewIndexer(indexPath, buildDir string) (*Indexer, error) {
-	indexMapping := bleve.NewIndexMapping()
+	bleveMapping := bleve.NewIndexMapping()
+
+	err := bleveMapping.AddCustomAnalyzer(SuggestAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add suggest analyzer")
+	}
 
 	return &Indexer{
-		indexMapping:     indexMapping,
+		indexMapping: &indexMapping{
+			IndexMappingImpl: bleveMapping,
+			languages:        map[string]string{},
+			analyzers:        map[string]bool{},
+			markup:           map[string]*fieldMarkup{},
+		},
 		indexPath:        indexPath,
 		buildDir:         buildDir,
 		documemtMappings: map[string]*mapping.DocumentMapping{},
@@ -71,10 +83,18 @@ func NewIndexer(indexPath, buildDir string) (*Indexer, error) {
 func (i *Indexer) Close() error {
 	if i.builder != nil {
 		err := i.builder.Close()
+		i.builder = nil
 		if err != nil {
 			return errors.Wrap(err, "failed to close builder")
 		}
 	}
+	if i.buildPath != "" {
+		err := os.RemoveAll(i.buildPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove build path %s", i.buildPath)
+		}
+		i.buildPath = ""
+	}
 
 	// Need to recursively update permissions on the index directory. Here is why:
 	// i.builder.Close will move the index from `buildDir` to `indexPath`.
@@ -96,21 +116,80 @@ func (i *Indexer) Close() error {
 	return nil
 }
 
+// Discard drops the index instead of creating it with Close,
+// e.g. when indexing fails: temporary files and the partially written
+// index are removed.
+func (i *Indexer) Discard() error {
+	i.builder = nil
+	if i.buildPath == "" {
+		// nothing is written yet, or the index is complete
+		return nil
+	}
+
+	paths := []string{i.buildPath, i.indexPath}
+	if i.buildDir != "" {
+		paths = append(paths, i.buildDir)
+	}
+	for _, path := range paths {
+		err := os.RemoveAll(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove %s", path)
+		}
+	}
+	i.buildPath = ""
+	return nil
+}
+
 func (i *Indexer) RegisterType(structType interface{}, lang string) error {
-	docType := i.getDocumentType(structType)
+	docType := getDocumentType(structType)
 
 	if _, ok := i.documemtMappings[docType]; ok {
 		return nil
 	}
 
+	typeLang := getDocumentLanguage(structType, lang)
+	if typeLang != "" && !i.indexMapping.hasAnalyzer(typeLang) {
+		return errors.Errorf("unsupported language %q of type %s", typeLang, docType)
+	}
+
+	markup := newFieldMarkup()
+	if err := getFieldMarkup(structType, "", markup); err != nil {
+		return errors.Wrapf(err, "failed to register type %s", docType)
+	}
+
 	docMapping := i.getDocumentMapping(structType, lang)
 
 	i.indexMapping.AddDocumentMapping(docType, docMapping)
+	i.indexMapping.languages[docType] = typeLang
+	i.indexMapping.markup[docType] = markup
 	i.documemtMappings[docType] = docMapping
 
 	return nil
 }
 
+// RegisterAnalyzer adds custom analyzer to the index mapping.
+// Its name may be used as a language of document types and documents.
+// Analyzers must be registered before the first document is indexed.
+func (i *Indexer) RegisterAnalyzer(config AnalyzerConfig) error {
+	if i.builder != nil {
+		return errors.Errorf("failed to register analyzer %s: indexing already started", config.Name)
+	}
+
+	err := addAnalyzer(i.indexMapping.IndexMappingImpl, config)
+	if err != nil {
+		return err
+	}
+	i.indexMapping.analyzers[config.Name] = true
+	return nil
+}
+
+// SetLanguageDetector enables language detection for documents
+// without their own language (see Language interface).
+// Detected language is used instead of the language passed to RegisterType.
+func (i *Indexer) SetLanguageDetector(detector *LanguageDetector) {
+	i.indexMapping.detector = detector
+}
+
 func (i *Indexer) Index(id string, data interface{}) error {
 	if i.builder == nil {
 		err := i.init()
@@ -119,7 +198,17 @@ func (i *Indexer) Index(id string, data interface{}) error {
 		}
 	}
 
-	return i.builder.Index(id, data)
+	// the document is mapped once, sections are split before markup is stripped
+	doc := document.NewDocument(id)
+	sections, err := i.indexMapping.mapDocument(doc, data)
+	if err != nil {
+		return err
+	}
+	err = i.builder.Index(id, mappedDocument{doc: doc})
+	if err != nil {
+		return err
+	}
+	return i.indexSections(id, sections)
 }
 
 func (i *Indexer) init() error {
@@ -130,19 +219,30 @@ func (i *Indexer) init() error {
 		}
 	}
 
+	// the builder creates its files in a directory of its own,
+	// so Discard can remove them
+	buildPath, err := os.MkdirTemp(i.buildDir, "indexer")
+	if err != nil {
+		return errors.Wrap(err, "failed to create build path")
+	}
+
 	config := map[string]interface{}{
-		"buildPathPrefix": i.buildDir,
+		"buildPathPrefix": buildPath,
 	}
 
-	var err error
+	// index mapping can't change once the index is created
+	i.indexMapping.addLocalizedMappings()
+
 	i.builder, err = bleve.NewBuilder(i.indexPath, i.indexMapping, config)
 	if err != nil {
+		os.RemoveAll(buildPath)
 		return errors.Wrapf(err, "failed to create %s", i.indexPath)
 	}
+	i.buildPath = buildPath
 	return nil
 }
 
-func (i *Indexer) getDocumentType(structType interface{}) string {
+func getDocumentType(structType interface{}) string {
 	classifier, ok := structType.(mapping.Classifier)
 	if !ok {
 		reflectType := reflect.TypeOf(structType)
@@ -152,7 +252,315 @@ func (i *Indexer) getDocumentType(structType interface{}) string {
 	return classifier.Type()
 }
 
-func (i *Indexer) getDocumentLanguage(structType interface{}, defaultLang string) string {
+// indexMapping extends bleve's index mapping with fields
+// which are added to every indexed document.
+type indexMapping struct {
+	*mapping.IndexMappingImpl
+
+	// languages holds the language of each registered document type,
+	// used for documents which don't specify their own language.
+	languages map[string]string
+
+	// analyzers holds names of registered custom analyzers.
+	analyzers map[string]bool
+
+	// markup holds markup options of text fields
+	// for each registered document type.
+	markup map[string]*fieldMarkup
+
+	// detector, if set, detects language of documents
+	// which don't implement Language interface.
+	detector *LanguageDetector
+}
+
+// MapDocument maps document the same way bleve does, then:
+//   - strips markup from text fields with `format` option,
+//   - stores parent ID and anchor of sections in `ParentField` and `AnchorField`,
+//     marks them with `SectionField` and removes their suggestion fields,
+//   - indexes text fields in localized fields (see LocalizedField), if the
+//     document language differs from the language its type was registered with,
+//   - stores document type in the `TypeField` (`_type` by default),
+//     so documents may be filtered by type at search time,
+//   - excludes these fields from the field searched by default,
+//   - stores document language in the `LanguageField`.
+func (m *indexMapping) MapDocument(doc *document.Document, data interface{}) error {
+	if mapped, ok := data.(mappedDocument); ok {
+		doc.Fields = mapped.doc.Fields
+		doc.CompositeFields = mapped.doc.CompositeFields
+		return nil
+	}
+
+	_, err := m.mapDocument(doc, data)
+	return err
+}
+
+// mappedDocument is a document mapped by Indexer.Index already.
+type mappedDocument struct {
+	doc *document.Document
+}
+
+// mapDocument maps the document (see MapDocument) and returns its sections.
+func (m *indexMapping) mapDocument(doc *document.Document, data interface{}) ([]sectionDocument, error) {
+	err := m.IndexMappingImpl.MapDocument(doc, data)
+	if err != nil {
+		return nil, err
+	}
+
+	docType := getDocumentType(data)
+	section, isSection := data.(sectionDocument)
+	var sections []sectionDocument
+	if isSection {
+		removeSuggestFields(doc)
+	} else {
+		sections, err = m.documentSections(doc, docType)
+		if err != nil {
+			return nil, err
+		}
+		// section values are plain text already
+		err = m.stripMarkup(doc, m.markup[docType])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	typeLang := m.languages[docType]
+	lang := m.documentLanguage(doc, data)
+	if lang != typeLang {
+		err = m.localize(doc, typeLang, lang)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// sections are in the language of the whole document
+	for i := range sections {
+		sections[i].Lang = lang
+	}
+
+	doc.AddField(
+		document.NewTextFieldWithIndexingOptions(
+			m.TypeField,
+			nil,
+			[]byte(docType),
+			index.IndexField|index.DocValues,
+		),
+	)
+	if lang != "" {
+		doc.AddField(
+			document.NewTextFieldWithIndexingOptions(
+				LanguageField,
+				nil,
+				[]byte(lang),
+				index.IndexField|index.DocValues,
+			),
+		)
+	}
+	if isSection {
+		doc.AddField(
+			document.NewTextFieldWithIndexingOptions(
+				ParentField,
+				nil,
+				[]byte(section.parentID),
+				index.IndexField|index.StoreField|index.DocValues,
+			),
+		)
+		doc.AddField(
+			document.NewTextFieldWithIndexingOptions(
+				AnchorField,
+				nil,
+				[]byte(section.anchor),
+				index.StoreField,
+			),
+		)
+		doc.AddField(
+			document.NewBooleanFieldWithIndexingOptions(
+				SectionField,
+				nil,
+				true,
+				index.IndexField,
+			),
+		)
+	}
+	m.excludeFromAll(doc, m.TypeField, LanguageField, ParentField, SectionField)
+	return sections, nil
+}
+
+// excludeFromAll rebuilds the composite field searched by default (`_all`),
+// so values of the given fields added after mapping, like document type,
+// don't match queries for any field. Bleve excludes `_id` and fields mapped
+// with IncludeInAll disabled, which are suggestion fields only.
+func (m *indexMapping) excludeFromAll(doc *document.Document, fields ...string) {
+	excluded := append([]string{"_id"}, fields...)
+	for _, field := range doc.Fields {
+		if strings.HasSuffix(field.Name(), SuggestFieldSuffix) {
+			excluded = append(excluded, field.Name())
+		}
+	}
+
+	for i, composite := range doc.CompositeFields {
+		if composite.Name() == m.DefaultField {
+			doc.CompositeFields[i] = document.NewCompositeFieldWithIndexingOptions(
+				composite.Name(), true, nil, excluded, composite.Options(),
+			)
+		}
+	}
+}
+
+// documentLanguage returns the language of the document: its own,
+// detected from its text fields or the language of its type.
+func (m *indexMapping) documentLanguage(doc *document.Document, data interface{}) string {
+	typeLang := m.languages[getDocumentType(data)]
+	lang := getDocumentLanguage(data, "")
+	if lang == "" && m.detector != nil {
+		lang = m.detector.Detect(m.languageText(doc, typeLang))
+	}
+	if lang == "" {
+		return typeLang
+	}
+	return lang
+}
+
+// stripMarkup replaces values of text fields having markup format with
+// their plain text. Fields are analyzed later, so the markup never becomes
+// terms, and the stored value is the plain text as well.
+func (m *indexMapping) stripMarkup(doc *document.Document, markup *fieldMarkup) error {
+	if markup == nil || len(markup.formats) == 0 {
+		return nil
+	}
+
+	for i, field := range doc.Fields {
+		textField, ok := field.(*document.TextField)
+		if !ok {
+			continue
+		}
+		format, ok := markup.formats[textField.Name()]
+		if !ok {
+			continue
+		}
+
+		text, err := StripMarkup(format, textField.Text())
+		if err != nil {
+			return errors.Wrapf(err, "failed to strip markup of field %s of document %s", textField.Name(), doc.ID())
+		}
+		doc.Fields[i] = document.NewTextFieldCustom(
+			textField.Name(),
+			textField.ArrayPositions(),
+			[]byte(text),
+			textField.Options(),
+			textField.Analyzer(),
+		)
+	}
+	return nil
+}
+
+// localize moves values of text fields analyzed with the `from` language analyzer
+// to their localized fields (see LocalizedField) analyzed with the `to` language
+// analyzer. The text fields keep stored values, so documents have the same fields
+// whatever their language is.
+func (m *indexMapping) localize(doc *document.Document, from, to string) error {
+	if !m.hasAnalyzer(to) {
+		return errors.Errorf("unsupported language %q of document %s", to, doc.ID())
+	}
+	toAnalyzer := m.AnalyzerNamed(to)
+
+	for _, i := range m.languageFields(doc, from) {
+		textField := doc.Fields[i].(*document.TextField)
+		options := textField.Options()
+		doc.Fields[i] = document.NewTextFieldWithIndexingOptions(
+			textField.Name(),
+			textField.ArrayPositions(),
+			textField.Value(),
+			options&index.StoreField,
+		)
+		// analyzers may lowercase the value in place, keep the stored one intact
+		value := append([]byte(nil), textField.Value()...)
+		doc.AddField(document.NewTextFieldCustom(
+			LocalizedField(textField.Name(), to),
+			textField.ArrayPositions(),
+			value,
+			options&^index.StoreField,
+			toAnalyzer,
+		))
+	}
+	return nil
+}
+
+// addLocalizedMappings maps localized fields of text fields analyzed with the language
+// of their type for every language, documents may be in any of them.
+func (m *indexMapping) addLocalizedMappings() {
+	languages := SupportedLanguages()
+	for name := range m.analyzers {
+		languages = append(languages, name)
+	}
+	sort.Strings(languages)
+
+	for docType, docMapping := range m.TypeMapping {
+		if lang := m.languages[docType]; lang != "" {
+			addLocalizedMappings(docMapping, lang, languages)
+		}
+	}
+}
+
+// addLocalizedMappings adds localized fields to properties of the document mapping,
+// as sub-properties named after languages, so "Title.ru" is analyzed with "ru" analyzer.
+func addLocalizedMappings(docMapping *mapping.DocumentMapping, typeLang string, languages []string) {
+	for _, property := range docMapping.Properties {
+		addLocalizedMappings(property, typeLang, languages)
+
+		for _, field := range property.Fields {
+			if field.Type != "text" || !field.Index || field.Name != "" || field.Analyzer != typeLang {
+				continue
+			}
+			for _, lang := range languages {
+				if lang == typeLang {
+					continue
+				}
+				localized := mapping.NewTextFieldMapping()
+				localized.Analyzer = lang
+				localized.Store = false
+				localized.IncludeTermVectors = field.IncludeTermVectors
+				localized.DocValues = field.DocValues
+
+				localizedMapping := mapping.NewDocumentMapping()
+				localizedMapping.AddFieldMapping(localized)
+				property.AddSubDocumentMapping(lang, localizedMapping)
+			}
+		}
+	}
+}
+
+// hasAnalyzer reports whether the name is a supported language,
+// a registered custom analyzer or any other analyzer known to bleve, e.g. "standard".
+func (m *indexMapping) hasAnalyzer(name string) bool {
+	return IsLanguageSupported(name) || m.analyzers[name] || m.AnalyzerNamed(name) != nil
+}
+
+// languageText joins values of text fields analyzed with the language analyzer.
+func (m *indexMapping) languageText(doc *document.Document, lang string) string {
+	var values []string
+	for _, i := range m.languageFields(doc, lang) {
+		values = append(values, doc.Fields[i].(*document.TextField).Text())
+	}
+	return strings.Join(values, "\n")
+}
+
+// languageFields returns positions of text fields analyzed with the language analyzer.
+func (m *indexMapping) languageFields(doc *document.Document, lang string) []int {
+	if lang == "" {
+		return nil
+	}
+	analyzer := m.AnalyzerNamed(lang)
+
+	var result []int
+	for i, field := range doc.Fields {
+		textField, ok := field.(*document.TextField)
+		if ok && textField.Analyzer() != nil && textField.Analyzer() == analyzer {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func getDocumentLanguage(structType interface{}, defaultLang string) string {
 	lang, ok := structType.(Language)
 	if !ok {
 		return defaultLang
@@ -167,40 +575,18 @@ func (i *Indexer) getDocumentLanguage(structType interface{}, defaultLang string
 
 func (i *Indexer) getDocumentMapping(structType interface{}, defaultLang string) *mapping.DocumentMapping {
 	docMapping := mapping.NewDocumentMapping()
-	lang := i.getDocumentLanguage(structType, defaultLang)
+	lang := getDocumentLanguage(structType, defaultLang)
 
 	reflectType := reflect.TypeOf(structType)
 	for f := 0; f < reflectType.NumField(); f++ {
 		field := reflectType.Field(f)
 
 		switch field.Type.Kind() {
-		case reflect.String:
-			intexerTag := field.Tag.Get("indexer")
-			if intexerTag == "" {
-				continue
-			}
-
-			switch intexerTag {
-			case "text":
-				textFieldMapping := mapping.NewTextFieldMapping()
-				textFieldMapping.Analyzer = lang
-				docMapping.AddFieldMappingsAt(field.Name, textFieldMapping)
-
-			case "date":
-				dateFieldMapping := mapping.NewDateTimeFieldMapping()
-				docMapping.AddFieldMappingsAt(field.Name, dateFieldMapping)
-
-			case "no_index":
-				noIndexFieldMapping := mapping.NewTextFieldMapping()
-				noIndexFieldMapping.Index = false
-				docMapping.AddFieldMappingsAt(field.Name, noIndexFieldMapping)
-
-			case "no_store":
-				noStoreFieldMapping := mapping.NewTextFieldMapping()
-				noStoreFieldMapping.Index = false
-				noStoreFieldMapping.Store = false
-				docMapping.AddFieldMappingsAt(field.Name, noStoreFieldMapping)
-			}
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			addFieldMapping(docMapping, field.Name, parseFieldTag(field.Tag.Get("indexer")), lang)
 
 		case reflect.Struct:
 			// recursion for nested structs
@@ -212,6 +598,154 @@ func (i *Indexer) getDocumentMapping(structType interface{}, defaultLang string)
 	return docMapping
 }
 
+// getFieldMarkup collects markup options of text fields tagged with
+// `format` option, e.g. `indexer:"text,format=html"`, by field path.
+func getFieldMarkup(structType interface{}, prefix string, markup *fieldMarkup) error {
+	reflectType := reflect.TypeOf(structType)
+	for f := 0; f < reflectType.NumField(); f++ {
+		field := reflectType.Field(f)
+		path := prefix + field.Name
+
+		switch field.Type.Kind() {
+		case reflect.String:
+			if err := markup.add(path, parseFieldTag(field.Tag.Get("indexer"))); err != nil {
+				return err
+			}
+
+		case reflect.Struct:
+			fieldValue := reflect.ValueOf(structType).FieldByName(field.Name).Interface()
+			if err := getFieldMarkup(fieldValue, path+".", markup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addFieldMapping adds mapping of the field with the `indexer` tag.
+// Fields without tag are left to bleve's dynamic mapping.
+func addFieldMapping(docMapping *mapping.DocumentMapping, name string, tag fieldTag, lang string) {
+	switch tag.kind {
+	case "text":
+		textFieldMapping := mapping.NewTextFieldMapping()
+		textFieldMapping.Analyzer = lang
+		if tag.has("sortable") {
+			textFieldMapping.DocValues = true
+		}
+		docMapping.AddFieldMappingsAt(name, textFieldMapping)
+
+		if tag.has("suggest") {
+			suggestFieldMapping := mapping.NewTextFieldMapping()
+			suggestFieldMapping.Name = name + SuggestFieldSuffix
+			suggestFieldMapping.Analyzer = SuggestAnalyzer
+			suggestFieldMapping.Store = false
+			suggestFieldMapping.IncludeInAll = false
+			suggestFieldMapping.IncludeTermVectors = false
+			suggestFieldMapping.DocValues = false
+			docMapping.AddFieldMappingsAt(name, suggestFieldMapping)
+		}
+
+	case "keyword":
+		keywordFieldMapping := mapping.NewTextFieldMapping()
+		keywordFieldMapping.Analyzer = keyword.Name
+		docMapping.AddFieldMappingsAt(name, keywordFieldMapping)
+
+	case "number":
+		numberFieldMapping := mapping.NewNumericFieldMapping()
+		docMapping.AddFieldMappingsAt(name, numberFieldMapping)
+
+	case "bool":
+		boolFieldMapping := mapping.NewBooleanFieldMapping()
+		docMapping.AddFieldMappingsAt(name, boolFieldMapping)
+
+	case "date":
+		dateFieldMapping := mapping.NewDateTimeFieldMapping()
+		docMapping.AddFieldMappingsAt(name, dateFieldMapping)
+
+	case "no_index":
+		noIndexFieldMapping := mapping.NewTextFieldMapping()
+		noIndexFieldMapping.Index = false
+		noIndexFieldMapping.DocValues = false
+		docMapping.AddFieldMappingsAt(name, noIndexFieldMapping)
+
+	case "no_store":
+		noStoreFieldMapping := mapping.NewTextFieldMapping()
+		noStoreFieldMapping.Index = false
+		noStoreFieldMapping.Store = false
+		noStoreFieldMapping.DocValues = false
+		docMapping.AddFieldMappingsAt(name, noStoreFieldMapping)
+	}
+}
+
+// fieldMarkup holds markup options of text fields of a document type.
+type fieldMarkup struct {
+	// formats of text fields by their path
+	formats map[string]string
+
+	// sections are paths of text fields split into sections
+	sections map[string]bool
+}
+
+func newFieldMarkup() *fieldMarkup {
+	return &fieldMarkup{formats: map[string]string{}, sections: map[string]bool{}}
+}
+
+// add records markup format of the text field at path and of its
+// suggestion field, if the tag has `format` option, and whether
+// the field is split into sections, if the tag has `sections` option.
+func (m *fieldMarkup) add(path string, tag fieldTag) error {
+	format, ok := tag.options["format"]
+	if !ok {
+		if tag.has("sections") {
+			return errors.Errorf("sections of field %s require format option", path)
+		}
+		return nil
+	}
+	if tag.kind != "text" {
+		return errors.Errorf("format of field %s can only be set for text fields", path)
+	}
+	if format != FormatHTML && format != FormatMarkdown {
+		return errors.Errorf("unsupported format %q of field %s", format, path)
+	}
+
+	m.formats[path] = format
+	if tag.has("suggest") {
+		m.formats[path+SuggestFieldSuffix] = format
+	}
+	if tag.has("sections") {
+		m.sections[path] = true
+	}
+	return nil
+}
+
+// fieldTag is a parsed `indexer` struct tag: the field kind followed by
+// optional comma-separated options, e.g. `indexer:"text,sortable"`.
+type fieldTag struct {
+	kind    string
+	options map[string]string
+}
+
+func parseFieldTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	result := fieldTag{
+		kind:    strings.TrimSpace(parts[0]),
+		options: map[string]string{},
+	}
+
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if key != "" {
+			result.options[key] = value
+		}
+	}
+	return result
+}
+
+func (t fieldTag) has(option string) bool {
+	_, ok := t.options[option]
+	return ok
+}
+
 func fixPermissions(path string, dirmode, filemode fs.FileMode) error {
 	return filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/blevesearch/bleve/v2/search/query"
+	indexapi "github.com/blevesearch/bleve_index_api"
 	"github.com/stretchr/testify/require"
 )
 
@@ -83,6 +85,33 @@ func (t tags) Type() string {
 	return "tags"
 }
 
+func TestIndexerDiscard(t *testing.T) {
+	type simple struct {
+		Text string
+	}
+
+	path := "ignore/discard"
+	buildDir := "ignore/discard_build"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, buildDir)
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(simple{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("alice", simple{Text: "Ping"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Discard()
+	require.NoError(t, err, "failed to discard index")
+
+	for _, path := range []string{path, buildDir} {
+		_, err = os.Stat(path)
+		require.True(t, os.IsNotExist(err), "%s is not removed", path)
+	}
+}
+
 func TestIndexerTags(t *testing.T) {
 	path := "ignore/tags"
 	os.RemoveAll(path)
@@ -208,3 +237,393 @@ func TestIndexerMultilang(t *testing.T) {
 	require.Equal(t, []string{"search"}, searchText(index, "search", t))
 	require.Equal(t, []string{"search_ru"}, searchText(index, "результат", t))
 }
+
+type sortable struct {
+	Title  string `indexer:"text,sortable"`
+	Author string `indexer:"text"`
+	Date   string `indexer:"date"`
+}
+
+func (s sortable) Type() string {
+	return "sortable"
+}
+
+func TestIndexerSortable(t *testing.T) {
+	path := "ignore/sortable"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(sortable{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("one", sortable{Title: "Bravo", Author: "alice", Date: "2006-01-01"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("two", sortable{Title: "Alpha", Author: "bob", Date: "2006-01-02"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	sorted := func(order ...string) []string {
+		request := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+		request.SortBy(order)
+		result, err := index.Search(request)
+		require.NoError(t, err, "failed to search")
+
+		hits := []string{}
+		for _, hit := range result.Hits {
+			hits = append(hits, hit.ID)
+		}
+		return hits
+	}
+
+	require.Equal(t, []string{"two", "one"}, sorted("Title"))
+	require.Equal(t, []string{"one", "two"}, sorted("-Title"))
+	require.Equal(t, []string{"one", "two"}, sorted("Author"))
+	require.Equal(t, []string{"two", "one"}, sorted("-Author"))
+
+	docMapping := index.Mapping().(*mapping.IndexMappingImpl).TypeMapping["sortable"]
+	require.True(t, docMapping.Properties["Author"].Fields[0].DocValues, "text fields keep doc values")
+	require.Equal(t, []string{"one", "two"}, sorted("Date"))
+	require.Equal(t, []string{"two", "one"}, sorted("-Date"))
+}
+
+func TestParseFieldTag(t *testing.T) {
+	tag := parseFieldTag("text, sortable,key=value")
+	require.Equal(t, "text", tag.kind)
+	require.True(t, tag.has("sortable"))
+	require.Equal(t, "value", tag.options["key"])
+	require.False(t, tag.has("other"))
+
+	require.Equal(t, "", parseFieldTag("").kind)
+}
+
+type page struct {
+	Title string `indexer:"text"`
+}
+
+func TestIndexerTypeField(t *testing.T) {
+	path := "ignore/type"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(tags{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.RegisterType(page{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("tags", tags{Text: "Ping"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("page", page{Title: "Ping"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	typeQuery := func(docType string) query.Query {
+		q := bleve.NewTermQuery(docType)
+		q.SetField("_type")
+		return q
+	}
+
+	require.Equal(t, []string{"tags"}, search(index, typeQuery("tags"), t))
+	require.Equal(t, []string{"page"}, search(index, typeQuery("page"), t))
+	require.Equal(t, []string{}, search(index, typeQuery("Post"), t))
+
+	// type names aren't searched by default
+	require.Empty(t, searchText(index, "page", t))
+	require.Empty(t, searchText(index, "tags", t))
+}
+
+type article struct {
+	Title string `indexer:"text"`
+	Lang  string
+}
+
+func (a article) Type() string {
+	return "article"
+}
+
+func (a article) Language() string {
+	return a.Lang
+}
+
+func TestIndexerDocumentLanguage(t *testing.T) {
+	path := "ignore/document_language"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(article{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("en", article{Title: "Searching", Lang: "en"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("ru", article{Title: "результаты", Lang: "ru"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("default", article{Title: "Running"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("unknown", article{Title: "Unknown", Lang: "xx"})
+	require.Error(t, err, "expected error for unknown language")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	require.Equal(t, []string{"en"}, searchText(index, "search", t))
+	require.Equal(t, []string{"ru"}, searchText(index, "результат", t))
+	require.Equal(t, []string{"default"}, searchText(index, "run", t))
+
+	langQuery := func(lang string) query.Query {
+		q := bleve.NewTermQuery(lang)
+		q.SetField(LanguageField)
+		return q
+	}
+
+	require.Equal(t, []string{"ru"}, search(index, langQuery("ru"), t))
+	require.ElementsMatch(t, []string{"en", "default"}, search(index, langQuery("en"), t))
+
+	// the localized field is mapped with the document language analyzer,
+	// so field queries are analyzed the same way
+	require.Equal(t, "ru", index.Mapping().AnalyzerNameForPath("Title.ru"))
+	require.Equal(t, []string{"ru"}, searchText(index, "Title.ru:результаты", t))
+	require.Empty(t, searchText(index, "Title:результаты", t))
+	require.Equal(t, []string{"en"}, searchText(index, "Title:searching", t))
+
+	// the text field keeps the stored value
+	doc, err := index.Document("ru")
+	require.NoError(t, err)
+	values := map[string]string{}
+	doc.VisitFields(func(field indexapi.Field) {
+		values[field.Name()] = string(field.Value())
+	})
+	require.Equal(t, "результаты", values["Title"])
+}
+
+type comment struct {
+	Text string `indexer:"text"`
+}
+
+func (c comment) Type() string {
+	return "comment"
+}
+
+func TestIndexerLanguageDetector(t *testing.T) {
+	path := "ignore/language_detector"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	indexer.SetLanguageDetector(NewLanguageDetector("en", "ru"))
+
+	err = indexer.RegisterType(comment{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("en", comment{Text: "The results of searching"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("ru", comment{Text: "Результаты поиска по сайту"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	require.Equal(t, []string{"en"}, searchText(index, "search", t))
+	require.Equal(t, []string{"ru"}, searchText(index, "результат", t))
+
+	langQuery := bleve.NewTermQuery("ru")
+	langQuery.SetField(LanguageField)
+	require.Equal(t, []string{"ru"}, search(index, langQuery, t))
+
+	// languages aren't searched by default
+	require.Empty(t, searchText(index, "en", t))
+	require.Empty(t, searchText(index, "ru", t))
+}
+
+func TestIndexerUnsupportedLanguage(t *testing.T) {
+	indexer, err := NewIndexer("ignore/unsupported", "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(page{}, "xx")
+	require.EqualError(t, err, `unsupported language "xx" of type page`)
+
+	// bleve built-in analyzers are accepted too
+	for _, name := range []string{"standard", "simple", "keyword", "web"} {
+		err = indexer.RegisterFields(name, map[string]string{"Title": "text"}, name)
+		require.NoError(t, err, name)
+	}
+}
+
+func TestIndexerCustomAnalyzer(t *testing.T) {
+	path := "ignore/custom_analyzer"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterAnalyzer(AnalyzerConfig{
+		Name:         "docs",
+		Language:     "en",
+		Synonyms:     []string{"k8s, kubernetes"},
+		ASCIIFolding: true,
+		StripHTML:    true,
+	})
+	require.NoError(t, err, "failed to register analyzer")
+
+	// analyzer without char filters
+	err = indexer.RegisterAnalyzer(AnalyzerConfig{Name: "plain", Language: "en"})
+	require.NoError(t, err, "failed to register analyzer")
+
+	err = indexer.RegisterType(comment{}, "docs")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("k8s", comment{Text: "<b>Deploying</b> to K8s"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("cafe", comment{Text: "Café menu"})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.RegisterAnalyzer(AnalyzerConfig{Name: "late"})
+	require.EqualError(t, err, "failed to register analyzer late: indexing already started")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	// analyzer definition is stored in the index mapping
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	require.Equal(t, []string{"k8s"}, searchText(index, "Text:kubernetes", t))
+	require.Equal(t, []string{"k8s"}, searchText(index, "Text:deploy", t))
+	require.Empty(t, searchText(index, "Text:b", t))
+	require.Equal(t, []string{"cafe"}, searchText(index, "Text:cafe", t))
+}
+
+type markupPost struct {
+	Title string `indexer:"text,format=html,suggest"`
+	Body  string `indexer:"text,format=markdown"`
+}
+
+func (p markupPost) Type() string {
+	return "markupPost"
+}
+
+func TestIndexerMarkup(t *testing.T) {
+	path := "ignore/markup"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(markupPost{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("post", markupPost{
+		Title: "<em>Deploying</em> services",
+		Body:  "Read the [guide](https://example.com/kubernetes).",
+	})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	require.Equal(t, []string{"post"}, searchText(index, "Title:deploy", t))
+	require.Equal(t, []string{"post"}, searchText(index, "Body:guide", t))
+	require.Empty(t, searchText(index, "Title:em", t))
+	require.Empty(t, searchText(index, "Body:kubernetes", t))
+	require.Empty(t, searchText(index, "Body:https", t))
+	require.Empty(t, searchText(index, "Title_suggest:em", t))
+
+	// stored values are plain text
+	doc, err := index.Document("post")
+	require.NoError(t, err)
+	values := map[string]string{}
+	doc.VisitFields(func(field indexapi.Field) {
+		values[field.Name()] = string(field.Value())
+	})
+	require.Equal(t, "Deploying services", values["Title"])
+	require.Equal(t, "Read the guide.", values["Body"])
+}
+
+func TestIndexerUnsupportedFormat(t *testing.T) {
+	indexer, err := NewIndexer("ignore/unsupported_format", "")
+	require.NoError(t, err, "failed to create indexer")
+
+	type rstPost struct {
+		Body string `indexer:"text,format=rst"`
+	}
+	err = indexer.RegisterType(rstPost{}, "en")
+	require.EqualError(t, err, `failed to register type rstPost: unsupported format "rst" of field Body`)
+}
+
+type product struct {
+	Name    string  `indexer:"text"`
+	SKU     string  `indexer:"keyword"`
+	Price   float64 `indexer:"number"`
+	Stock   int     `indexer:"number"`
+	InStock bool    `indexer:"bool"`
+}
+
+func (p product) Type() string {
+	return "product"
+}
+
+func TestIndexerFieldKinds(t *testing.T) {
+	path := "ignore/field_kinds"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(product{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("cheap", product{Name: "Cable", SKU: "CB-01 A", Price: 5, Stock: 10, InStock: true})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Index("expensive", product{Name: "Router", SKU: "RT-02", Price: 150})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	skuQuery := bleve.NewTermQuery("CB-01 A")
+	skuQuery.SetField("SKU")
+	require.Equal(t, []string{"cheap"}, search(index, skuQuery, t))
+	require.Empty(t, searchText(index, "SKU:cb", t))
+	require.Equal(t, []string{"expensive"}, searchText(index, "Price:>100", t))
+	require.Equal(t, []string{"cheap"}, searchText(index, "Stock:>=10", t))
+
+	inStockQuery := bleve.NewBoolFieldQuery(true)
+	inStockQuery.SetField("InStock")
+	require.Equal(t, []string{"cheap"}, search(index, inStockQuery, t))
+}
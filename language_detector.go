@@ -0,0 +1,163 @@
+package search
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// maxNgram is the length of the longest character n-gram used for detection.
+const maxNgram = 3
+
+// smoothing is added to n-gram counts, so n-grams missing
+// from the sample text don't have zero probability.
+const smoothing = 0.1
+
+// languageSamples are texts used to build n-gram profiles of languages
+// along with languageWords: the first article of the Universal Declaration of Human Rights.
+var languageSamples = map[string]string{
+	"ar":  "يولد جميع الناس أحرارًا متساوين في الكرامة والحقوق. وقد وهبوا عقلاً وضميرًا وعليهم أن يعامل بعضهم بعضًا بروح الإخاء.",
+	"cjk": "人人生而自由，在尊严和权利上一律平等。他们赋有理性和良心，并应以兄弟关系的精神相对待。すべての人間は、生まれながらにして自由であり、かつ、尊厳と権利とについて平等である。모든 인간은 태어날 때부터 자유로우며 그 존엄과 권리에 있어 동등하다.",
+	"ckb": "هەموو مرۆڤەکان بە ئازادی و یەکسانی لە ڕێز و مافەکاندا لەدایک دەبن. هەموویان خاوەنی ئەقڵ و هۆشن و پێویستە لەگەڵ یەکتردا بە ڕۆحی برایەتی ڕەفتار بکەن.",
+	"da":  "Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og samvittighed, og de bør handle mod hverandre i en broderskabets ånd.",
+	"de":  "Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen.",
+	"en":  "All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood.",
+	"es":  "Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros.",
+	"fa":  "تمام افراد بشر آزاد به دنیا می‌آیند و از لحاظ حیثیت و حقوق با هم برابرند. همه دارای عقل و وجدان هستند و باید نسبت به یکدیگر با روح برادری رفتار کنند.",
+	"fi":  "Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä.",
+	"fr":  "Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité.",
+	"hi":  "सभी मनुष्यों को गौरव और अधिकारों के मामले में जन्मजात स्वतन्त्रता और समानता प्राप्त है। उन्हें बुद्धि और अन्तरात्मा की देन प्राप्त है और परस्पर उन्हें भाईचारे के भाव से बर्ताव करना चाहिए।",
+	"hr":  "Sva ljudska bića rađaju se slobodna i jednaka u dostojanstvu i pravima. Ona su obdarena razumom i sviješću pa jedna prema drugima trebaju postupati u duhu bratstva.",
+	"hu":  "Minden emberi lény szabadon születik és egyenlő méltósága és joga van. Az emberek, ésszel és lelkiismerettel bírván, egymással szemben testvéri szellemben kell hogy viseltessenek.",
+	"it":  "Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza.",
+	"nl":  "Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen.",
+	"no":  "Alle mennesker er født frie og med samme menneskeverd og menneskerettigheter. De er utstyrt med fornuft og samvittighet og bør handle mot hverandre i brorskapets ånd.",
+	"pt":  "Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade.",
+	"ro":  "Toate ființele umane se nasc libere și egale în demnitate și în drepturi. Ele sunt înzestrate cu rațiune și conștiință și trebuie să se comporte unele față de altele în spiritul fraternității.",
+	"ru":  "Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и совестью и должны поступать в отношении друг друга в духе братства.",
+	"sv":  "Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap.",
+	"tr":  "Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler.",
+}
+
+// languageWordCount is the number of words of each language in languageWords.
+const languageWordCount = 100
+
+// languageWords are common words of languages, languageWordCount distinct words of each.
+var languageWords = map[string]string{
+	"ar":  "في من على إلى أن هذا التي الذي عن مع كان ما لا هو هي قد كل بين ذلك هذه لم ثم أو إذا بعد عند كما حتى غير منذ أي نحن هم أنا أنت يوم عام بيت ماء عمل ولد مدينة بلد كبير صغير جيد جديد الآن اليوم دائما لأن أيضا نعم شكرا كانت يكون هناك فقط أكثر لكن حيث عليه إن أنه فيها منها له لها به كيف لماذا متى أين هنا قبل الذين وقت سنة شيء رجل امرأة يد عين طريق كتاب باب أرض سماء شمس ليلة قال يقول رأى ذهب جاء أخذ عرف أراد كثير قليل",
+	"cjk": "的 一 是 不 了 在 人 有 我 他 这 个 们 中 来 上 大 为 和 国 地 到 以 说 时 要 就 出 会 可 の に は を た が で て と し れ さ ある いる こと 이 그 는 을 에 하다 年 生 能 子 自 那 得 也 于 下 你 她 好 看 天 去 过 家 学 对 小 多 然 后 心 日 本 もの する よう なる この その から まで 나 우리 것 수 있다 없다 한 고 가 도 로 와 사람 때",
+	"ckb": "و لە بە کە ئەم ئەو بۆ دا لەگەڵ هەموو یان بوو دەبێت هەیە نییە من تۆ ئەوان ئێمە ئێوە چی کێ کوێ کەی بۆچی چۆن ئێرە ئەوێ گەورە بچووک باش خراپ نوێ کۆن مرۆڤ کات ساڵ ڕۆژ ماڵ ئاو کار منداڵ شار وڵات زۆر ئێستا ئەمڕۆ هەمیشە چونکە بەڵێ سوپاس لەسەر بەڵام یا ئەگەر تەنها هەروەها دوای پێش لای ناو سەر دەست چاو ڕێگا کتێب دەرگا زەوی ئاسمان خۆر شەو پیاو ئافرەت کچ کوڕ باوک دایک برا خوشک دەڵێت دەکات دەچێت دێت دەزانێت دەیەوێت جوان درێژ سپی ڕەش سەوز سوور زیاتر کەم یەکەم دوایین سبەینێ دوێنێ پێکەوە ڕەنگە نا",
+	"da":  "og i at det er en til på som de med han af for ikke der var mig sig men et har om vi min havde jeg hun nu over da fra du ud sin dem os op man hans hvor eller hvad skal selv her alle vil kunne ind når være dog noget ville jo deres efter ned skulle denne end dette mit også hvornår hvorfor hvordan stor lille god dårlig gammel menneske tid år dag hus vand arbejde barn by land meget altid aldrig sammen derfor fordi måske stadig ja tak ny kvinde mand bog vej dør hånd",
+	"de":  "der die und in den von zu das mit sich des auf für ist im dem nicht ein eine als auch es an werden aus er hat dass sie nach wird bei einer um am sind noch wie einem über einen so zum war haben nur oder aber vor bis sein wurde sei ich du wir ihr dieses jenes wo wann warum hier dort groß klein gut schlecht neu alt Mensch Zeit Jahr Tag Haus Wasser Arbeit Kind Stadt Land sehr jetzt heute morgen gestern immer nie zusammen deshalb weil vielleicht ja danke Frau Mann Buch Weg Tür Hand Auge",
+	"en":  "the of and to a in is it you that he was for on are with as his they be at one have this from or had by not word but what some we can out other were all there when up use your how said an each she which if will way about many then them would write like so these her long make thing see him two has look more day could go come did my me new old big small good bad person year house water work child city country very now today tomorrow yesterday always never",
+	"es":  "de la que el en y a los se del las un por con no una su para es al lo como más o pero sus le ha me si sin sobre este ya entre cuando todo esta ser son dos también fue había era muy años hasta desde está yo tú él ella nosotros ellos eso esto donde porque cómo aquí allí grande pequeño bueno malo nuevo viejo persona tiempo año día casa agua trabajo niño ciudad país mucho ahora hoy mañana ayer siempre nunca juntos entonces embargo todavía sí gracias mi mujer hombre libro camino puerta mano ojo",
+	"fa":  "و در به از که این را با است برای آن یک خود تا کرد بر هم نیز شد می ها شده او ما من تو آنها کجا چرا چگونه اینجا بزرگ کوچک خوب بد نو انسان زمان سال روز خانه آب کار کودک شهر کشور بسیار اکنون امروز همیشه زیرا بله ی یا هر اما اگر چه شود دارد بود باید خواهد کند گفت پس بین دیگر همه چند وقتی قبل بعد کنار زیر روی دست چشم راه کتاب خورشید شب مرد زن پدر مادر برادر خواهر رفت آمد دانست خواست زیبا بلند سفید سیاه بیشتر کم اول آخر",
+	"fi":  "ja on ei se että hän oli olla mutta kun niin tai joka myös ovat ole kuin mitä jos vain sitä tämä hänen minä sen nyt siitä kanssa ne jo mukaan sekä vielä sinä me te he tuo missä milloin miksi miten täällä siellä iso pieni hyvä huono uusi vanha ihminen aika vuosi päivä talo vesi työ lapsi kaupunki maa hyvin tänään huomenna eilen aina koskaan yhdessä siksi koska ehkä kyllä kiitos minun sinun meidän heidän nainen mies kirja tie ovi käsi silmä yö aurinko sanoa mennä tulla tehdä nähdä tietää haluaa paljon vähän ensimmäinen viimeinen kaunis pitkä valkoinen musta",
+	"fr":  "de la le et les des en un du une que est pour qui dans par plus pas au sur ne se ce il sont avec son elle ou mais comme on tout nous sa aux été leur cette fait ses je ont aussi bien entre même sans deux très tu vous ils elles ceci cela où quand pourquoi comment ici là grand petit bon mauvais nouveau vieux personne temps année jour maison eau travail enfant ville pays maintenant demain hier toujours jamais ensemble donc parce encore oui merci mon ma femme homme livre chemin porte main œil nuit dire",
+	"hi":  "के है में की और को से का एक यह पर भी नहीं था कि हैं ने लिए तो जो कर वह हो गया थे अपने या इस कुछ साथ बाद कहा जब मैं तुम हम वे यहाँ वहाँ क्यों कैसे बड़ा छोटा अच्छा नया समय साल दिन घर पानी काम लेकिन अगर सब बहुत फिर अब आज कल हमेशा कभी क्योंकि शायद हाँ धन्यवाद मेरा तेरा उसका हमारा आदमी औरत बच्चा किताब रास्ता दरवाजा हाथ आँख रात सूरज पिता माता भाई बहन कहना जाना आना देखना जानना चाहना ज्यादा कम पहला आखिरी सुंदर लंबा सफेद काला लाल शहर देश",
+	"hr":  "i je u da se na su za od s to a ne koji kao iz o ali bi što sam će biti ili jer sve samo još kako tako može nije bio bila ja ti on ona mi vi oni ovo ono gdje kada zašto ovdje tamo velik mali dobar loš nov star čovjek vrijeme godina dan kuća voda posao dijete grad zemlja vrlo sada danas sutra jučer uvijek nikada zajedno zato možda također hvala moj tvoj naš žena muškarac knjiga put vrata ruka oko noć sunce reći ići doći raditi vidjeti znati htjeti mnogo malo prvi posljednji lijep",
+	"hu":  "a az és hogy nem is egy van meg de ez már csak el mint ki azt volt még vagy kell sem lesz minden után amely pedig úgy között én te ő mi ti ők ami aki hol mikor miért hogyan itt ott nagy kicsi jó rossz régi ember idő év nap ház víz munka gyerek város ország szép nagyon most ma holnap tegnap mindig soha együtt azonban ezért mert lehet akar tud megy jön enyém tiéd nő férfi könyv út ajtó kéz szem éjszaka napfény mond lát ismer csinál sok kevés első utolsó hosszú fehér fekete piros zöld kék",
+	"it":  "di e il la che in a per un del non è una sono le si con i da al come lo più ma della anche gli ci nel se questo alla ha io dei cui tutto essere fatto suo quando molto tu lui lei noi loro quello dove perché qui grande piccolo buono cattivo nuovo vecchio persona tempo anno giorno casa acqua lavoro bambino città paese adesso oggi domani ieri sempre mai insieme allora però ancora sì grazie mio tuo nostro donna uomo libro strada porta mano occhio notte sole dire andare venire vedere sapere volere poco primo ultimo",
+	"nl":  "de en van het een in is dat op te zijn die voor met niet aan er om als ook maar bij dan nog uit wordt door naar heeft was hij of meer kan wel al worden ik je wat we hebben dit mijn ze hem zo zou kunnen moet hier goed nu gaan weet waar toch nee ja heel wil weer onze jullie jaar mensen tijd huis werk kinderen groot klein water dag week morgen vandaag gisteren altijd nooit samen omdat misschien dank jouw vrouw man boek weg deur hand oog nacht zon zeggen komen zien weten veel weinig",
+	"no":  "og i det er som en på til av at for med de ikke den har jeg om et men var han fra vi kan så seg ble skal hun eller også etter bare nå når ut dette hadde mot være du dere her der hvorfor hvordan stor liten god dårlig gammel menneske tid år dag hus vann arbeid barn by land veldig alltid aldri sammen derfor fordi kanskje fortsatt ja takk min din vår kvinne mann bok vei dør hånd øye natt sol si gå komme gjøre se vite vil mye lite første siste vakker lang hvit svart rød",
+	"pt":  "de a o que e do da em um para é com não uma os no se na por mais as dos como mas foi ao ele das tem à seu sua ou ser quando muito há nos já está eu também só pelo pela até isso você ela nós eles isto aquilo onde porque aqui ali grande pequeno bom mau novo velho pessoa tempo ano dia casa água trabalho criança cidade país agora hoje amanhã ontem sempre nunca juntos então porém ainda sim obrigado minha meu nossa mulher homem livro caminho porta mão olho noite sol dizer ir vir",
+	"ro":  "și de la în a cu pe că nu o un care se din mai ce fi este pentru sunt au fost sau dar ca prin după această acest ei lui eu tu el ea noi voi ele acesta acela unde când cum aici acolo mare mic bun rău nou vechi om timp an zi casă apă muncă copil oraș țară foarte acum azi mâine ieri mereu niciodată împreună deci poate încă da mulțumesc meu tău nostru femeie bărbat carte drum ușă mână ochi noapte soare spune merge veni face vedea ști vrea mult puțin primul ultimul frumos lung alb",
+	"ru":  "и в не на я что он с как а то все она так его но да ты к у же вы за бы по только ее мне было вот от меня еще нет о из ему теперь когда даже ну вдруг ли если уже или ни быть был него до вас нас нее сам раз тут где есть надо ней для мы тебя их чем была чтоб без будто чего тоже себе под будет ж тогда кто этот того потому этого какой совсем ним здесь этом один почти мой тем чтобы сейчас были куда зачем всех никогда можно при",
+	"sv":  "och i att det som en på är av för med till den har de inte om ett han men var jag sig från vi så kan man när år säger hon under också efter eller nu sin där vid mot ska skulle du ni här varför hur stor liten bra dålig gammal människa tid dag hus vatten arbete barn stad land mycket idag imorgon igår alltid aldrig tillsammans därför eftersom kanske fortfarande ja tack min din vår kvinna bok väg dörr hand öga natt sol säga gå komma göra se veta vill lite första sista vacker lång vit svart",
+	"tr":  "bir ve bu da de için ile çok olarak daha gibi en her ne ama kadar sonra olan ki o var değil veya ya şey ben sen biz onlar oldu olduğu siz bunu şunu nerede zaman neden nasıl burada orada büyük küçük iyi kötü eski insan yıl gün ev su iş çocuk şehir ülke şimdi bugün yarın dün asla birlikte yüzden çünkü belki hâlâ evet teşekkürler benim senin bizim kadın adam kitap yol kapı el göz gece güneş söylemek gitmek gelmek yapmak görmek bilmek istemek az ilk son güzel uzun beyaz siyah kırmızı yeşil yeni arkadaş anne baba kim hangi",
+}
+
+// LanguageDetector is an offline language detector based on character n-gram profiles.
+// It is reliable for sentences, but may be wrong for one or two word texts,
+// so it's better to limit detection to languages which are actually expected.
+type LanguageDetector struct {
+	profiles map[string]ngramProfile
+	// vocabulary is the number of distinct n-grams in all profiles
+	vocabulary int
+}
+
+type ngramProfile struct {
+	counts map[string]int
+	total  int
+}
+
+// NewLanguageDetector creates detector for the given languages.
+// All languages with known profiles are used if none are given,
+// languages without profiles are ignored.
+func NewLanguageDetector(languages ...string) *LanguageDetector {
+	if len(languages) == 0 {
+		for lang := range languageSamples {
+			languages = append(languages, lang)
+		}
+	}
+
+	profiles := map[string]ngramProfile{}
+	vocabulary := map[string]bool{}
+	for _, lang := range languages {
+		sample, ok := languageSamples[lang]
+		if !ok {
+			continue
+		}
+
+		profile := ngramProfile{counts: ngrams(sample + " " + languageWords[lang])}
+		for ngram, count := range profile.counts {
+			profile.total += count
+			vocabulary[ngram] = true
+		}
+		profiles[lang] = profile
+	}
+
+	return &LanguageDetector{profiles: profiles, vocabulary: len(vocabulary)}
+}
+
+// Languages returns the number of languages the detector can choose from.
+func (d *LanguageDetector) Languages() int {
+	return len(d.profiles)
+}
+
+// Detect returns the most probable language of the text
+// or empty string if the text has no letters or detector has no languages.
+func (d *LanguageDetector) Detect(text string) string {
+	textNgrams := ngrams(text)
+	if len(textNgrams) == 0 {
+		return ""
+	}
+
+	best, bestScore := "", math.Inf(-1)
+	for lang, profile := range d.profiles {
+		// log-likelihood of the text n-grams with additive smoothing
+		score := 0.0
+		denominator := float64(profile.total) + smoothing*float64(d.vocabulary)
+		for ngram, count := range textNgrams {
+			probability := (float64(profile.counts[ngram]) + smoothing) / denominator
+			score += float64(count) * math.Log(probability)
+		}
+
+		if score > bestScore || score == bestScore && lang < best {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
+
+// ngrams counts character n-grams from 1 to maxNgram of lowercased words of the text.
+// Words are padded with spaces, so n-grams at word boundaries are distinguished.
+func ngrams(text string) map[string]int {
+	result := map[string]int{}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxNgram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				ngram := string(runes[i : i+n])
+				if ngram != " " {
+					result[ngram]++
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageDetector(t *testing.T) {
+	detector := NewLanguageDetector()
+
+	tt := []struct {
+		text string
+		want string
+	}{
+		{text: "The quick brown fox jumps over the lazy dog", want: "en"},
+		{text: "Der schnelle braune Fuchs springt über den faulen Hund", want: "de"},
+		{text: "Le renard brun rapide saute par-dessus le chien paresseux", want: "fr"},
+		{text: "El rápido zorro marrón salta sobre el perro perezoso", want: "es"},
+		{text: "Быстрая коричневая лиса прыгает через ленивую собаку", want: "ru"},
+		{text: "La volpe veloce salta sopra il cane pigro", want: "it"},
+		{text: "Eu comprei uma bicicleta nova para a minha filha", want: "pt"},
+		{text: "Jag har köpt en ny cykel till min dotter", want: "sv"},
+		{text: "Kızım için yeni bir bisiklet aldım", want: "tr"},
+		{text: "Vettem egy új biciklit a lányomnak", want: "hu"},
+		{text: "敏捷的棕色狐狸跳过了懒狗", want: "cjk"},
+		{text: "तेज़ भूरी लोमड़ी आलसी कुत्ते के ऊपर कूदती है", want: "hi"},
+		{text: "123 !?", want: ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.text, func(t *testing.T) {
+			require.Equal(t, tc.want, detector.Detect(tc.text))
+		})
+	}
+}
+
+func TestLanguageDetectorCandidates(t *testing.T) {
+	detector := NewLanguageDetector("en", "ru", "xx")
+	require.Equal(t, 2, detector.Languages())
+
+	require.Equal(t, "en", detector.Detect("searching"))
+	require.Equal(t, "ru", detector.Detect("поиск"))
+
+	require.Equal(t, "", NewLanguageDetector("xx").Detect("searching"))
+}
+
+func TestLanguageProfiles(t *testing.T) {
+	require.Equal(t, len(languageSamples), len(languageWords))
+
+	// samples are the same article, so they are of similar size
+	// and end with the sentence, not with other text
+	shortest := 0
+	for _, sample := range languageSamples {
+		if length := utf8.RuneCountInString(sample); shortest == 0 || length < shortest {
+			shortest = length
+		}
+	}
+	for lang, sample := range languageSamples {
+		require.LessOrEqual(t, utf8.RuneCountInString(sample), 2*shortest, "size of %s sample", lang)
+		require.True(t, strings.HasSuffix(sample, ".") || strings.HasSuffix(sample, "।"), "end of %s sample", lang)
+	}
+
+	for lang := range languageSamples {
+		words := strings.Fields(languageWords[lang])
+		require.Len(t, words, languageWordCount, "number of words of %s", lang)
+
+		seen := map[string]bool{}
+		for _, word := range words {
+			require.False(t, seen[word], "duplicated word %q of %s", word, lang)
+			seen[word] = true
+		}
+	}
+}
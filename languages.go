@@ -0,0 +1,151 @@
+package search
+
+import (
+	"sort"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/v2/registry"
+
+	// bleve built-in analyzers may be used instead of languages
+	_ "github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	_ "github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	_ "github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	_ "github.com/blevesearch/bleve/v2/analysis/analyzer/web"
+
+	// language packages register their analyzers, stop words and token filters in bleve
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ar"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/bg"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ca"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ckb"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/cs"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/da"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/de"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/el"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/en"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/es"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/eu"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fa"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fi"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ga"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/gl"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hi"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hr"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hu"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hy"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/id"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/in"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/it"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/no"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ro"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/sv"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/tr"
+)
+
+// supportedLanguages maps codes of supported languages to their names.
+// Code is the name of bleve analyzer for the language. Some of the language
+// packages imported above (bg, ca, cs, el, eu, ga, gl, hy, id, in) don't
+// provide an analyzer, only stop words and token filters, so they are not listed.
+var supportedLanguages = map[string]string{
+	"ar":  "Arabic",
+	"cjk": "Chinese, Japanese, Korean",
+	"ckb": "Sorani Kurdish",
+	"da":  "Danish",
+	"de":  "German",
+	"en":  "English",
+	"es":  "Spanish",
+	"fa":  "Persian",
+	"fi":  "Finnish",
+	"fr":  "French",
+	"hi":  "Hindi",
+	"hr":  "Croatian",
+	"hu":  "Hungarian",
+	"it":  "Italian",
+	"nl":  "Dutch",
+	"no":  "Norwegian",
+	"pt":  "Portuguese",
+	"ro":  "Romanian",
+	"ru":  "Russian",
+	"sv":  "Swedish",
+	"tr":  "Turkish",
+}
+
+// SupportedLanguages returns sorted codes of supported languages.
+func SupportedLanguages() []string {
+	result := make([]string, 0, len(supportedLanguages))
+	for code := range supportedLanguages {
+		result = append(result, code)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// IsLanguageSupported reports whether the language code is supported.
+func IsLanguageSupported(code string) bool {
+	_, ok := supportedLanguages[code]
+	return ok
+}
+
+// LanguageName returns English name of the supported language
+// or empty string for unknown language code.
+func LanguageName(code string) string {
+	return supportedLanguages[code]
+}
+
+// analysisChain describes how bleve analyzer of a language processes text,
+// so custom analyzers may reuse it partially.
+type analysisChain struct {
+	charFilters  []string
+	tokenFilters []string // except stemmer
+	stop         string   // stop words filter in tokenFilters
+	stemmer      string
+}
+
+// normalizeNFKC is the name of the token filter which normalizes tokens
+// to Unicode NFKC form, e.g. Arabic presentation forms and ligatures.
+// Bleve's Arabic analyzer creates such filter itself, without a name.
+const normalizeNFKC = "normalize_nfkc"
+
+// languageChains repeats analyzers of bleve language packages.
+var languageChains = map[string]analysisChain{
+	"ar":  {tokenFilters: []string{"to_lower", normalizeNFKC, "stop_ar", "normalize_ar"}, stop: "stop_ar", stemmer: "stemmer_ar"},
+	"cjk": {tokenFilters: []string{"cjk_width", "to_lower", "cjk_bigram"}},
+	"ckb": {tokenFilters: []string{"normalize_ckb", "to_lower", "stop_ckb"}, stop: "stop_ckb", stemmer: "stemmer_ckb"},
+	"da":  {tokenFilters: []string{"to_lower", "stop_da"}, stop: "stop_da", stemmer: "stemmer_da_snowball"},
+	"de":  {tokenFilters: []string{"to_lower", "stop_de", "normalize_de"}, stop: "stop_de", stemmer: "stemmer_de_light"},
+	"en":  {tokenFilters: []string{"possessive_en", "to_lower", "stop_en"}, stop: "stop_en", stemmer: "stemmer_porter"},
+	"es":  {tokenFilters: []string{"to_lower", "stop_es"}, stop: "stop_es", stemmer: "stemmer_es_light"},
+	"fa": {
+		charFilters:  []string{"zero_width_spaces"},
+		tokenFilters: []string{"normalize_ar", "normalize_fa", "to_lower", "stop_fa"},
+		stop:         "stop_fa",
+	},
+	"fi": {tokenFilters: []string{"to_lower", "stop_fi"}, stop: "stop_fi", stemmer: "stemmer_fi_snowball"},
+	"fr": {tokenFilters: []string{"elision_fr", "to_lower", "stop_fr"}, stop: "stop_fr", stemmer: "stemmer_fr_light"},
+	"hi": {tokenFilters: []string{"to_lower", "normalize_in", "normalize_hi", "stop_hi"}, stop: "stop_hi", stemmer: "stemmer_hi"},
+	"hr": {
+		tokenFilters: []string{"to_lower", "stop_hr", "hr_suffix_transformation_filter"},
+		stop:         "stop_hr",
+		stemmer:      "stemmer_hr",
+	},
+	"hu": {tokenFilters: []string{"to_lower", "stop_hu"}, stop: "stop_hu", stemmer: "stemmer_hu_snowball"},
+	"it": {tokenFilters: []string{"elision_it", "to_lower", "stop_it"}, stop: "stop_it", stemmer: "stemmer_it_light"},
+	"nl": {tokenFilters: []string{"to_lower", "stop_nl"}, stop: "stop_nl", stemmer: "stemmer_nl_snowball"},
+	"no": {tokenFilters: []string{"to_lower", "stop_no"}, stop: "stop_no", stemmer: "stemmer_no_snowball"},
+	"pt": {tokenFilters: []string{"to_lower", "stop_pt"}, stop: "stop_pt", stemmer: "stemmer_pt_light"},
+	"ro": {tokenFilters: []string{"to_lower", "stop_ro"}, stop: "stop_ro", stemmer: "stemmer_ro_snowball"},
+	"ru": {tokenFilters: []string{"to_lower", "stop_ru"}, stop: "stop_ru", stemmer: "stemmer_ru_snowball"},
+	"sv": {tokenFilters: []string{"to_lower", "stop_sv"}, stop: "stop_sv", stemmer: "stemmer_sv_snowball"},
+	"tr": {tokenFilters: []string{"apostrophe", "to_lower", "stop_tr"}, stop: "stop_tr", stemmer: "stemmer_tr_snowball"},
+}
+
+func init() {
+	registry.RegisterTokenFilter(normalizeNFKC, func(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+		return unicodenorm.NewUnicodeNormalizeFilter(unicodenorm.NFKC)
+	})
+}
@@ -0,0 +1,24 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportedLanguages(t *testing.T) {
+	cache := registry.NewCache()
+	for _, code := range SupportedLanguages() {
+		_, err := cache.AnalyzerNamed(code)
+		require.NoError(t, err, "no analyzer for supported language %s", code)
+		require.NotEmpty(t, LanguageName(code))
+	}
+
+	for code := range languageSamples {
+		require.True(t, IsLanguageSupported(code), "detector profile for unsupported language %s", code)
+	}
+
+	require.False(t, IsLanguageSupported("bg"))
+	require.Equal(t, "", LanguageName("xx"))
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yuin/goldmark"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Formats of text fields with markup, set with `format` option of `indexer` tag,
+// e.g. `indexer:"text,format=markdown"`.
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// StripMarkup returns plain text of the HTML or Markdown source:
+// tags, attributes (like link URLs), scripts and styles are removed,
+// block elements are separated with new lines.
+func StripMarkup(format, source string) (string, error) {
+	switch format {
+	case FormatHTML:
+		return stripHTML(source), nil
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := goldmark.Convert([]byte(source), &buf); err != nil {
+			return "", errors.Wrap(err, "failed to convert markdown")
+		}
+		return stripHTML(buf.String()), nil
+	}
+	return "", errors.Errorf("unsupported format %q", format)
+}
+
+// stripHTML extracts text from HTML.
+func stripHTML(source string) string {
+	var text strings.Builder
+	skip := 0 // depth of elements which content is not text
+
+	tokenizer := html.NewTokenizer(strings.NewReader(source))
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			// io.EOF or a read error, the source is a string, so it's the end
+			return collapseSpaces(text.String())
+
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(tokenizer.Text())
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := atom.Lookup(name)
+			switch {
+			case skippedElements[tag] && tokenType == html.StartTagToken:
+				skip++
+			case skippedElements[tag] && tokenType == html.EndTagToken:
+				if skip > 0 {
+					skip--
+				}
+			case blockElements[tag]:
+				text.WriteString("\n")
+			}
+		}
+	}
+}
+
+// skippedElements have content which is not a part of the text.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// blockElements separate text, so words around them don't stick together.
+var blockElements = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Blockquote: true,
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.Nav:        true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Td:         true,
+	atom.Th:         true,
+	atom.Tr:         true,
+	atom.Ul:         true,
+}
+
+// collapseSpaces trims lines, collapses spaces inside them and drops empty lines.
+func collapseSpaces(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripMarkup(t *testing.T) {
+	tt := []struct {
+		name   string
+		format string
+		source string
+		want   string
+	}{
+		{
+			name:   "html",
+			format: FormatHTML,
+			source: `<h1>Title</h1><p>Read the <a href="https://example.com/docs">docs</a>&amp;more.</p>`,
+			want:   "Title\nRead the docs&more.",
+		},
+		{
+			name:   "html scripts and styles",
+			format: FormatHTML,
+			source: `<style>p { color: red }</style><p>Text</p><script>alert("x")</script>`,
+			want:   "Text",
+		},
+		{
+			name:   "html blocks",
+			format: FormatHTML,
+			source: "<ul><li>one</li><li>two</li></ul>first<br>second",
+			want:   "one\ntwo\nfirst\nsecond",
+		},
+		{
+			name:   "markdown",
+			format: FormatMarkdown,
+			source: "# Title\n\nSee [the guide](https://example.com/guide) and `code`.\n\n- item\n",
+			want:   "Title\nSee the guide and code.\nitem",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := StripMarkup(tc.format, tc.source)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := StripMarkup("rst", "text")
+	require.EqualError(t, err, `unsupported format "rst"`)
+}
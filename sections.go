@@ -0,0 +1,217 @@
+package search
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2/document"
+	"github.com/pkg/errors"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ParentField is the name of the field which stores the ID of the document
+// a section belongs to. Sections of text fields with `sections` option,
+// e.g. `indexer:"text,format=markdown,sections"`, are indexed as separate
+// documents with IDs "<parent ID>#<anchor>".
+const ParentField = "_parent"
+
+// AnchorField is the name of the field which stores the anchor of a section,
+// the id of its heading.
+const AnchorField = "_anchor"
+
+// SectionField is the name of the boolean field which is true for sections,
+// so they may be told apart from documents with a single term.
+const SectionField = "_section"
+
+// sectionDocument is a section of a document: the same type and language,
+// the section text in the field it comes from.
+type sectionDocument struct {
+	Document
+
+	parentID string
+	anchor   string
+}
+
+// section is a part of a text field from a heading till the next heading.
+type section struct {
+	anchor string
+	text   string // heading and the text after it
+}
+
+// documentSections splits text fields of the mapped document with `sections` option
+// into section documents, their language is set once it's known.
+func (m *indexMapping) documentSections(doc *document.Document, docType string) ([]sectionDocument, error) {
+	markup := m.markup[docType]
+	if markup == nil || len(markup.sections) == 0 {
+		return nil, nil
+	}
+
+	var sections []sectionDocument
+	anchors := map[string]int{}
+	for _, field := range doc.Fields {
+		textField, ok := field.(*document.TextField)
+		if !ok || !markup.sections[textField.Name()] {
+			continue
+		}
+
+		parts, err := splitSections(markup.formats[textField.Name()], textField.Text())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to split field %s of document %s into sections", textField.Name(), doc.ID())
+		}
+		for _, part := range parts {
+			// anchors are unique within the document, like heading ids
+			anchor := part.anchor
+			if n := anchors[part.anchor]; n > 0 {
+				anchor += "-" + strconv.Itoa(n)
+			}
+			anchors[part.anchor]++
+
+			sections = append(sections, sectionDocument{
+				Document: Document{
+					Fields:  Fields{textField.Name(): part.text},
+					DocType: docType,
+				},
+				parentID: doc.ID(),
+				anchor:   anchor,
+			})
+		}
+	}
+	return sections, nil
+}
+
+// indexSections indexes sections of the document as separate documents.
+func (i *Indexer) indexSections(id string, sections []sectionDocument) error {
+	for _, s := range sections {
+		if err := i.builder.Index(id+"#"+s.anchor, s); err != nil {
+			return errors.Wrapf(err, "failed to index section %s of document %s", s.anchor, id)
+		}
+	}
+	return nil
+}
+
+// removeSuggestFields removes suggestion fields of the section,
+// so its terms are suggested once, for the whole document.
+func removeSuggestFields(doc *document.Document) {
+	fields := doc.Fields[:0]
+	for _, field := range doc.Fields {
+		if !strings.HasSuffix(field.Name(), SuggestFieldSuffix) {
+			fields = append(fields, field)
+		}
+	}
+	doc.Fields = fields
+}
+
+// splitSections splits HTML or Markdown source at headings. Text before
+// the first heading is not a section. Anchors are ids of headings,
+// generated from their text if missing.
+func splitSections(format, source string) ([]section, error) {
+	switch format {
+	case FormatHTML:
+		return splitHTMLSections(source), nil
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		md := goldmark.New(goldmark.WithParserOptions(parser.WithAutoHeadingID()))
+		if err := md.Convert([]byte(source), &buf); err != nil {
+			return nil, errors.Wrap(err, "failed to convert markdown")
+		}
+		return splitHTMLSections(buf.String()), nil
+	}
+	return nil, errors.Errorf("unsupported format %q", format)
+}
+
+func splitHTMLSections(source string) []section {
+	type rawSection struct {
+		anchor  string
+		heading strings.Builder
+		html    strings.Builder
+	}
+	var sections []*rawSection
+	var current *rawSection
+	var heading atom.Atom // heading element the tokenizer is in
+
+	tokenizer := html.NewTokenizer(strings.NewReader(source))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		raw := string(tokenizer.Raw())
+
+		switch tokenType {
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if tag := atom.Lookup(name); heading == 0 && IsHeading(tag) {
+				heading = tag
+				current = &rawSection{}
+				sections = append(sections, current)
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					if string(key) == "id" {
+						current.anchor = string(value)
+					}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if atom.Lookup(name) == heading {
+				heading = 0
+			}
+		case html.TextToken:
+			if heading != 0 {
+				current.heading.WriteString(html.UnescapeString(raw))
+			}
+		}
+
+		if current != nil {
+			current.html.WriteString(raw)
+		}
+	}
+
+	result := make([]section, 0, len(sections))
+	for _, s := range sections {
+		anchor := s.anchor
+		if anchor == "" {
+			anchor = slugify(s.heading.String())
+		}
+		result = append(result, section{anchor: anchor, text: stripHTML(s.html.String())})
+	}
+	return result
+}
+
+// IsHeading reports whether the HTML element is a heading, h1 to h6.
+func IsHeading(a atom.Atom) bool {
+	switch a {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// slugify makes an anchor of the heading text: lowercase letters and digits
+// separated by hyphens, "section" if there are none.
+func slugify(text string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			hyphen = false
+			slug.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			hyphen = true
+		}
+	}
+	if slug.Len() == 0 {
+		return "section"
+	}
+	return slug.String()
+}
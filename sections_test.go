@@ -0,0 +1,114 @@
+package search
+
+import (
+	"os"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSections(t *testing.T) {
+	sections, err := splitSections(FormatMarkdown, "Intro text.\n\n# Getting started\n\nInstall it.\n\n## Next *steps*\n\nRead [docs](https://example.com).\n")
+	require.NoError(t, err)
+	require.Equal(t, []section{
+		{anchor: "getting-started", text: "Getting started\nInstall it."},
+		{anchor: "next-steps", text: "Next steps\nRead docs."},
+	}, sections)
+
+	sections, err = splitSections(FormatHTML, `<p>Intro</p><h2 id="setup">Set up</h2><p>A</p><h3>Caf&eacute; &amp; Bar!</h3><p>B</p><h4></h4>`)
+	require.NoError(t, err)
+	require.Equal(t, []section{
+		{anchor: "setup", text: "Set up\nA"},
+		{anchor: "café-bar", text: "Café & Bar!\nB"},
+		{anchor: "section", text: ""},
+	}, sections)
+
+	sections, err = splitSections(FormatHTML, "<p>No headings</p>")
+	require.NoError(t, err)
+	require.Empty(t, sections)
+}
+
+type guide struct {
+	Title string `indexer:"text"`
+	Body  string `indexer:"text,format=markdown,sections,suggest"`
+}
+
+func (g guide) Type() string {
+	return "guide"
+}
+
+func TestIndexerSections(t *testing.T) {
+	path := "ignore/sections"
+	os.RemoveAll(path)
+
+	indexer, err := NewIndexer(path, "")
+	require.NoError(t, err, "failed to create indexer")
+
+	err = indexer.RegisterType(guide{}, "en")
+	require.NoError(t, err, "failed to register type")
+
+	err = indexer.Index("/guide", guide{
+		Title: "Kubernetes guide",
+		Body:  "Intro.\n\n## Install\n\nUse helm charts.\n\n## Upgrade\n\nRoll out releases.\n\n## Install\n\nVerify pods.\n",
+	})
+	require.NoError(t, err, "failed to index")
+
+	err = indexer.Close()
+	require.NoError(t, err, "failed to close indexer")
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err, "failed to open index")
+
+	require.ElementsMatch(t, []string{"/guide", "/guide#upgrade"}, searchText(index, "Body:roll", t))
+	require.ElementsMatch(t, []string{"/guide", "/guide#install"}, searchText(index, "helm", t))
+	require.ElementsMatch(t, []string{"/guide", "/guide#install-1"}, searchText(index, "Body:pods", t))
+	require.Equal(t, []string{"/guide"}, searchText(index, "Title:kubernetes", t))
+	require.ElementsMatch(t, []string{"/guide#install", "/guide#upgrade", "/guide#install-1"}, search(index, parentQuery("/guide"), t))
+	require.ElementsMatch(t, []string{"/guide", "/guide#install", "/guide#upgrade", "/guide#install-1"}, searchText(index, "_type:guide", t))
+	sections := bleve.NewBoolFieldQuery(true)
+	sections.SetField(SectionField)
+	require.ElementsMatch(t, []string{"/guide#install", "/guide#upgrade", "/guide#install-1"}, search(index, sections, t))
+	// parent IDs and section marks aren't searched by default
+	require.Empty(t, search(index, bleve.NewTermQuery("/guide"), t))
+	require.Empty(t, search(index, bleve.NewTermQuery("T"), t))
+
+	// sections are documents of the index, but their terms aren't suggested again
+	count, err := index.DocCount()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+	dict, err := index.FieldDict("Body" + SuggestFieldSuffix)
+	require.NoError(t, err)
+	defer dict.Close()
+	for entry, err := dict.Next(); entry != nil; entry, err = dict.Next() {
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), entry.Count, "count of %q", entry.Term)
+	}
+
+	request := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{"/guide#upgrade"}))
+	request.Fields = []string{ParentField, AnchorField, "Body", LanguageField}
+	result, err := index.Search(request)
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	require.Equal(t, "/guide", result.Hits[0].Fields[ParentField])
+	require.Equal(t, "upgrade", result.Hits[0].Fields[AnchorField])
+	require.Equal(t, "Upgrade\nRoll out releases.", result.Hits[0].Fields["Body"])
+}
+
+func TestIndexerSectionsRequireFormat(t *testing.T) {
+	indexer, err := NewIndexer("ignore/sections_format", "")
+	require.NoError(t, err, "failed to create indexer")
+
+	type plain struct {
+		Body string `indexer:"text,sections"`
+	}
+	err = indexer.RegisterType(plain{}, "en")
+	require.EqualError(t, err, "failed to register type plain: sections of field Body require format option")
+}
+
+func parentQuery(id string) *query.TermQuery {
+	q := bleve.NewTermQuery(id)
+	q.SetField(ParentField)
+	return q
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/pkg/errors"
+)
+
+// SynonymFilter is the type of token filter which expands tokens with their synonyms.
+// Its config holds "synonyms": a list of rules in Solr format.
+const SynonymFilter = "synonyms"
+
+// Synonyms maps words to the words they are expanded to.
+type Synonyms map[string][]string
+
+// ParseSynonyms parses synonym rules in Solr format, one rule per line:
+//   - `k8s, kubernetes` – equivalent words, each expands to all of them,
+//   - `tv => television` – explicit mapping, word is replaced with the right side.
+//
+// Empty lines and lines starting with `#` are ignored. Words are lowercased.
+func ParseSynonyms(r io.Reader) (Synonyms, error) {
+	result := Synonyms{}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if err := result.add(scanner.Text()); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read synonyms")
+	}
+	return result, nil
+}
+
+func (s Synonyms) add(rule string) error {
+	rule = strings.TrimSpace(rule)
+	if rule == "" || strings.HasPrefix(rule, "#") {
+		return nil
+	}
+
+	left, right, explicit := strings.Cut(rule, "=>")
+	words, err := synonymWords(left)
+	if err != nil {
+		return err
+	}
+
+	if !explicit {
+		for _, word := range words {
+			s[word] = appendUnique(s[word], words...)
+		}
+		return nil
+	}
+
+	replacements, err := synonymWords(right)
+	if err != nil {
+		return err
+	}
+	for _, word := range words {
+		s[word] = appendUnique(s[word], replacements...)
+	}
+	return nil
+}
+
+// synonymWords parses comma-separated list of single words.
+func synonymWords(list string) ([]string, error) {
+	var result []string
+	for _, item := range strings.Split(list, ",") {
+		word := strings.ToLower(strings.TrimSpace(item))
+		if word == "" {
+			continue
+		}
+		if strings.ContainsAny(word, " \t") {
+			return nil, errors.Errorf("multi-word synonym %q is not supported", word)
+		}
+		result = append(result, word)
+	}
+	if len(result) == 0 {
+		return nil, errors.Errorf("no words in %q", list)
+	}
+	return result, nil
+}
+
+func appendUnique(list []string, words ...string) []string {
+	for _, word := range words {
+		found := false
+		for _, item := range list {
+			if item == word {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, word)
+		}
+	}
+	return list
+}
+
+// Filter replaces tokens having synonyms with tokens of all their synonyms
+// at the same position, so any of them matches. Tokens are matched
+// case-insensitively, so the filter may go before lowercasing.
+func (s Synonyms) Filter(input analysis.TokenStream) analysis.TokenStream {
+	result := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		synonyms, ok := s[strings.ToLower(string(token.Term))]
+		if !ok {
+			result = append(result, token)
+			continue
+		}
+
+		for _, synonym := range synonyms {
+			expanded := *token
+			expanded.Term = []byte(synonym)
+			result = append(result, &expanded)
+		}
+	}
+	return result
+}
+
+func synonymFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	var rules []string
+	switch value := config["synonyms"].(type) {
+	case []string:
+		rules = value
+	case []interface{}:
+		// config read from the index mapping JSON
+		for _, item := range value {
+			rule, ok := item.(string)
+			if !ok {
+				return nil, errors.Errorf("synonym rule must be a string, got %v", item)
+			}
+			rules = append(rules, rule)
+		}
+	default:
+		return nil, errors.New("must specify synonyms")
+	}
+
+	return ParseSynonyms(strings.NewReader(strings.Join(rules, "\n")))
+}
+
+func init() {
+	registry.RegisterTokenFilter(SynonymFilter, synonymFilterConstructor)
+}
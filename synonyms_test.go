@@ -0,0 +1,49 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSynonyms(t *testing.T) {
+	synonyms, err := ParseSynonyms(strings.NewReader(`
+# abbreviations
+k8s, Kubernetes
+tv => television, telly
+k8s, kube
+`))
+	require.NoError(t, err)
+	require.Equal(t, Synonyms{
+		"k8s":        {"k8s", "kubernetes", "kube"},
+		"kubernetes": {"k8s", "kubernetes"},
+		"kube":       {"k8s", "kube"},
+		"tv":         {"television", "telly"},
+	}, synonyms)
+
+	_, err = ParseSynonyms(strings.NewReader("k8s, kubernetes\nnew york, nyc"))
+	require.EqualError(t, err, `line 2: multi-word synonym "new york" is not supported`)
+
+	_, err = ParseSynonyms(strings.NewReader("tv =>"))
+	require.EqualError(t, err, `line 1: no words in ""`)
+}
+
+func TestSynonymsFilter(t *testing.T) {
+	synonyms := Synonyms{"tv": {"television", "telly"}}
+
+	output := synonyms.Filter(analysis.TokenStream{
+		{Term: []byte("smart"), Position: 1},
+		{Term: []byte("TV"), Position: 2},
+	})
+
+	var terms []string
+	var positions []int
+	for _, token := range output {
+		terms = append(terms, string(token.Term))
+		positions = append(positions, token.Position)
+	}
+	require.Equal(t, []string{"smart", "television", "telly"}, terms)
+	require.Equal(t, []int{1, 2, 2}, positions)
+}